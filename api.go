@@ -32,8 +32,21 @@ type S3 struct {
 	*s3.S3
 }
 
-func New(s *s3.S3) *S3 {
-	return &S3{s}
+// New returns an ECS client built on a copy of s. The request options opts
+// apply to every request of the client as with NewWithOptions. The handlers
+// of s are left unchanged, so clients created from the same s do not affect
+// each other or s.
+func New(s *s3.S3, opts ...request.Option) *S3 {
+	cl := *s.Client
+	cl.Handlers = s.Handlers.Copy()
+	c := &S3{&s3.S3{Client: &cl}}
+	c.Handlers.UnmarshalError.Remove(errorHandler)
+	c.Handlers.UnmarshalError.PushBackNamed(errorHandler)
+	c.Handlers.Validate.RemoveByName(clientOptionsHandlerName)
+	if len(opts) > 0 {
+		c.Handlers.Validate.PushFrontNamed(clientOptionsHandler(opts))
+	}
+	return c
 }
 
 var initRequest func(*request.Request)
@@ -125,6 +138,77 @@ func (c *S3) DeleteBucketMetadataSearchWithContext(ctx aws.Context, input *Delet
 	return out, req.Send()
 }
 
+const opGetBucketIsStaleAllowed = "GetBucketIsStaleAllowed"
+
+// GetBucketIsStaleAllowedRequest generates a request.Request
+func (c *S3) GetBucketIsStaleAllowedRequest(input *GetBucketIsStaleAllowedInput) (req *request.Request, output *GetBucketIsStaleAllowedOutput) {
+	op := &request.Operation{
+		Name:       opGetBucketIsStaleAllowed,
+		HTTPMethod: "HEAD",
+		HTTPPath:   "/{Bucket}",
+	}
+
+	if input == nil {
+		input = &GetBucketIsStaleAllowedInput{}
+	}
+
+	output = &GetBucketIsStaleAllowedOutput{}
+	req = c.newRequest(op, input, output)
+	return
+}
+
+// GetBucketIsStaleAllowed API operation for ECS Extension.
+//
+// It returns the access during outage settings of a bucket, as set by
+// CreateBucketExtension or PutBucketIsStaleAllowed.
+func (c *S3) GetBucketIsStaleAllowed(input *GetBucketIsStaleAllowedInput) (*GetBucketIsStaleAllowedOutput, error) {
+	req, out := c.GetBucketIsStaleAllowedRequest(input)
+	return out, req.Send()
+}
+
+// GetBucketIsStaleAllowedWithContext is the same as GetBucketIsStaleAllowed with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) GetBucketIsStaleAllowedWithContext(ctx aws.Context, input *GetBucketIsStaleAllowedInput, opts ...request.Option) (*GetBucketIsStaleAllowedOutput, error) {
+	req, out := c.GetBucketIsStaleAllowedRequest(input)
+	req.SetContext(ctx)
+	req.ApplyOptions(opts...)
+	return out, req.Send()
+}
+
+const opGetBucketRetention = "GetBucketRetention"
+
+// GetBucketRetentionRequest generates a request.Request
+func (c *S3) GetBucketRetentionRequest(input *GetBucketRetentionInput) (req *request.Request, output *GetBucketRetentionOutput) {
+	op := &request.Operation{
+		Name:       opGetBucketRetention,
+		HTTPMethod: "HEAD",
+		HTTPPath:   "/{Bucket}",
+	}
+
+	if input == nil {
+		input = &GetBucketRetentionInput{}
+	}
+
+	output = &GetBucketRetentionOutput{}
+	req = c.newRequest(op, input, output)
+	return
+}
+
+// GetBucketRetention API operation for ECS Extension.
+func (c *S3) GetBucketRetention(input *GetBucketRetentionInput) (*GetBucketRetentionOutput, error) {
+	req, out := c.GetBucketRetentionRequest(input)
+	return out, req.Send()
+}
+
+// GetBucketRetentionWithContext is the same as GetBucketRetention with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) GetBucketRetentionWithContext(ctx aws.Context, input *GetBucketRetentionInput, opts ...request.Option) (*GetBucketRetentionOutput, error) {
+	req, out := c.GetBucketRetentionRequest(input)
+	req.SetContext(ctx)
+	req.ApplyOptions(opts...)
+	return out, req.Send()
+}
+
 const opGetObject = "GetObject"
 
 // GetObjectExtensionRequest generates a request.Request
@@ -159,6 +243,40 @@ func (c *S3) GetObjectExtensionWithContext(ctx aws.Context, input *s3.GetObjectI
 	return out, req.Send()
 }
 
+const opGetObjectRetention = "GetObjectRetention"
+
+// GetObjectRetentionRequest generates a request.Request
+func (c *S3) GetObjectRetentionRequest(input *GetObjectRetentionInput) (req *request.Request, output *GetObjectRetentionOutput) {
+	op := &request.Operation{
+		Name:       opGetObjectRetention,
+		HTTPMethod: "HEAD",
+		HTTPPath:   "/{Bucket}/{Key+}",
+	}
+
+	if input == nil {
+		input = &GetObjectRetentionInput{}
+	}
+
+	output = &GetObjectRetentionOutput{}
+	req = c.newRequest(op, input, output)
+	return
+}
+
+// GetObjectRetention API operation for ECS Extension.
+func (c *S3) GetObjectRetention(input *GetObjectRetentionInput) (*GetObjectRetentionOutput, error) {
+	req, out := c.GetObjectRetentionRequest(input)
+	return out, req.Send()
+}
+
+// GetObjectRetentionWithContext is the same as GetObjectRetention with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) GetObjectRetentionWithContext(ctx aws.Context, input *GetObjectRetentionInput, opts ...request.Option) (*GetObjectRetentionOutput, error) {
+	req, out := c.GetObjectRetentionRequest(input)
+	req.SetContext(ctx)
+	req.ApplyOptions(opts...)
+	return out, req.Send()
+}
+
 const opGetSystemMetadataSearchKeys = "GetSystemMetadataSearchKeys"
 
 // GetSystemMetadataSearchKeysRequest generates request.Request
@@ -303,6 +421,11 @@ func (c *S3) ListBucketQueryRequest(input *ListBucketQueryInput) (req *request.R
 		Name:       opListBucketQuery,
 		HTTPMethod: "GET",
 		HTTPPath:   "/{Bucket}?query",
+		Paginator: &request.Paginator{
+			InputTokens:  []string{"Marker"},
+			OutputTokens: []string{"NextMarker"},
+			LimitToken:   "MaxKeys",
+		},
 	}
 
 	if input == nil {
@@ -311,6 +434,7 @@ func (c *S3) ListBucketQueryRequest(input *ListBucketQueryInput) (req *request.R
 
 	output = &ListBucketQueryOutput{}
 	req = c.newRequest(op, input, output)
+	req.Handlers.Unmarshal.PushBack(clearLastPageMarker)
 	return
 }
 
@@ -329,6 +453,41 @@ func (c *S3) ListBucketQueryWithContext(ctx aws.Context, input *ListBucketQueryI
 	return out, req.Send()
 }
 
+// ListBucketQueryPages iterates over the pages of a ListBucketQuery operation,
+// calling the "fn" function with the response data for each page. To stop
+// iterating, return false from the fn function.
+func (c *S3) ListBucketQueryPages(input *ListBucketQueryInput, fn func(*ListBucketQueryOutput, bool) bool) error {
+	return c.ListBucketQueryPagesWithContext(aws.BackgroundContext(), input, fn)
+}
+
+// ListBucketQueryPagesWithContext same as ListBucketQueryPages except
+// it takes a Context and allows setting request options on the pages.
+func (c *S3) ListBucketQueryPagesWithContext(ctx aws.Context, input *ListBucketQueryInput, fn func(*ListBucketQueryOutput, bool) bool, opts ...request.Option) error {
+	p := c.listBucketQueryPagination(ctx, input, opts...)
+
+	cont := true
+	for p.Next() && cont {
+		cont = fn(p.Page().(*ListBucketQueryOutput), !p.HasNextPage())
+	}
+	return p.Err()
+}
+
+func (c *S3) listBucketQueryPagination(ctx aws.Context, input *ListBucketQueryInput, opts ...request.Option) *request.Pagination {
+	return &request.Pagination{
+		NewRequest: func() (*request.Request, error) {
+			var inCpy *ListBucketQueryInput
+			if input != nil {
+				tmp := *input
+				inCpy = &tmp
+			}
+			req, _ := c.ListBucketQueryRequest(inCpy)
+			req.SetContext(ctx)
+			req.ApplyOptions(opts...)
+			return req, nil
+		},
+	}
+}
+
 const opPutBucketIsStaleAllowed = "PutBucketIsStaleAllowed"
 
 // PutBucketIsStaleAllowedRequest generates request.Request
@@ -351,6 +510,9 @@ func (c *S3) PutBucketIsStaleAllowedRequest(input *PutBucketIsStaleAllowedInput)
 }
 
 // PutBucketIsStaleAllowed API operation for ECS Extension
+//
+// It sets the access during outage settings of a bucket. ListDuringOutage and
+// TSOReadOnly are left unchanged when nil.
 func (c *S3) PutBucketIsStaleAllowed(input *PutBucketIsStaleAllowedInput) (*PutBucketIsStaleAllowedOutput, error) {
 	req, out := c.PutBucketIsStaleAllowedRequest(input)
 	return out, req.Send()
@@ -365,6 +527,47 @@ func (c *S3) PutBucketIsStaleAllowedWithContext(ctx aws.Context, input *PutBucke
 	return out, req.Send()
 }
 
+const opPutBucketRetention = "PutBucketRetention"
+
+// PutBucketRetentionRequest generates a request.Request
+func (c *S3) PutBucketRetentionRequest(input *PutBucketRetentionInput) (req *request.Request, output *PutBucketRetentionOutput) {
+	op := &request.Operation{
+		Name:       opPutBucketRetention,
+		HTTPMethod: "PUT",
+		HTTPPath:   "/{Bucket}?retentionperiod",
+	}
+
+	if input == nil {
+		input = &PutBucketRetentionInput{}
+	}
+
+	output = &PutBucketRetentionOutput{}
+	req = c.newRequest(op, input, output)
+	req.Handlers.Build.PushFrontNamed(request.NamedHandler{Name: retentionReductionCheckName, Fn: c.checkRetentionReduction})
+	req.Handlers.Unmarshal.Remove(restxml.UnmarshalHandler)
+	req.Handlers.Unmarshal.PushBackNamed(protocol.UnmarshalDiscardBodyHandler)
+	return
+}
+
+// PutBucketRetention API operation for ECS Extension.
+//
+// Retention of a compliance-enabled bucket can only be extended; reducing it
+// fails with ErrCodeRetentionReduction before the request is sent. The check
+// costs a HEAD request, which WithoutRetentionReductionCheck skips.
+func (c *S3) PutBucketRetention(input *PutBucketRetentionInput) (*PutBucketRetentionOutput, error) {
+	req, out := c.PutBucketRetentionRequest(input)
+	return out, req.Send()
+}
+
+// PutBucketRetentionWithContext is the same as PutBucketRetention with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) PutBucketRetentionWithContext(ctx aws.Context, input *PutBucketRetentionInput, opts ...request.Option) (*PutBucketRetentionOutput, error) {
+	req, out := c.PutBucketRetentionRequest(input)
+	req.SetContext(ctx)
+	req.ApplyOptions(opts...)
+	return out, req.Send()
+}
+
 const opPutObject = "PutObject"
 
 // PutObjectExtensionRequest generates a request.Request
@@ -399,6 +602,42 @@ func (c *S3) PutObjectExtensionWithContext(ctx aws.Context, input *PutObjectInpu
 	return out, req.Send()
 }
 
+const opPutObjectRetention = "PutObjectRetention"
+
+// PutObjectRetentionRequest generates a request.Request
+func (c *S3) PutObjectRetentionRequest(input *PutObjectRetentionInput) (req *request.Request, output *PutObjectRetentionOutput) {
+	op := &request.Operation{
+		Name:       opPutObjectRetention,
+		HTTPMethod: "PUT",
+		HTTPPath:   "/{Bucket}/{Key+}?retention",
+	}
+
+	if input == nil {
+		input = &PutObjectRetentionInput{}
+	}
+
+	output = &PutObjectRetentionOutput{}
+	req = c.newRequest(op, input, output)
+	req.Handlers.Unmarshal.Remove(restxml.UnmarshalHandler)
+	req.Handlers.Unmarshal.PushBackNamed(protocol.UnmarshalDiscardBodyHandler)
+	return
+}
+
+// PutObjectRetention API operation for ECS Extension.
+func (c *S3) PutObjectRetention(input *PutObjectRetentionInput) (*PutObjectRetentionOutput, error) {
+	req, out := c.PutObjectRetentionRequest(input)
+	return out, req.Send()
+}
+
+// PutObjectRetentionWithContext is the same as PutObjectRetention with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) PutObjectRetentionWithContext(ctx aws.Context, input *PutObjectRetentionInput, opts ...request.Option) (*PutObjectRetentionOutput, error) {
+	req, out := c.PutObjectRetentionRequest(input)
+	req.SetContext(ctx)
+	req.ApplyOptions(opts...)
+	return out, req.Send()
+}
+
 type CreateBucketInput struct {
 	_ struct{} `type:"structure" payload:"CreateBucketConfiguration"`
 
@@ -418,13 +657,15 @@ type CreateBucketInput struct {
 	// Allows grantee to create, overwrite, and delete any object in the bucket.
 	GrantWrite *string `location:"header" locationName:"x-amz-grant-write" type:"string"`
 	// Allows grantee to write the ACL for the applicable bucket.
-	GrantWriteACP   *string `location:"header" locationName:"x-amz-grant-write-acp" type:"string"`
-	IsStaleAllowed  *bool   `location:"header" locationName:"x-emc-is-stale-allowed" type:"boolean"`
-	MetadataSearch  *string `location:"header" locationName:"x-emc-metadata-search" type:"string"`
-	NameSpace       *string `location:"header" locationName:"x-emc-namespace" type:"string"`
-	RetentionPeriod *int64  `location:"header" locationName:"x-emc-retention-period" type:"integer"`
-	SSEEnabled      *bool   `location:"header" locationName:"x-emc-server-side-encryption-enabled" type:"boolean"`
-	VPool           *string `location:"header" locationName:"x-emc-vpool" type:"string"`
+	GrantWriteACP    *string `location:"header" locationName:"x-amz-grant-write-acp" type:"string"`
+	IsStaleAllowed   *bool   `location:"header" locationName:"x-emc-is-stale-allowed" type:"boolean"`
+	ListDuringOutage *bool   `location:"header" locationName:"x-emc-list-during-outage" type:"boolean"`
+	MetadataSearch   *string `location:"header" locationName:"x-emc-metadata-search" type:"string"`
+	NameSpace        *string `location:"header" locationName:"x-emc-namespace" type:"string"`
+	RetentionPeriod  *int64  `location:"header" locationName:"x-emc-retention-period" type:"integer"`
+	SSEEnabled       *bool   `location:"header" locationName:"x-emc-server-side-encryption-enabled" type:"boolean"`
+	TSOReadOnly      *bool   `location:"header" locationName:"x-emc-tso-read-only" type:"boolean"`
+	VPool            *string `location:"header" locationName:"x-emc-vpool" type:"string"`
 }
 
 // String returns the string representation
@@ -443,6 +684,11 @@ func (s *CreateBucketInput) Validate() error {
 	if s.Bucket == nil {
 		invalidParams.Add(request.NewErrParamRequired("Bucket"))
 	}
+	if s.MetadataSearch != nil {
+		if _, err := ParseMetadataSearchIndex(*s.MetadataSearch); err != nil {
+			invalidParams.Add(NewErrParamFormat("MetadataSearch", err.Error()))
+		}
+	}
 
 	if invalidParams.Len() > 0 {
 		return invalidParams
@@ -516,12 +762,28 @@ func (s *CreateBucketInput) SetIsStaleAllowed(v bool) *CreateBucketInput {
 	return s
 }
 
+// SetListDuringOutage sets the ListDuringOutage field's value.
+func (s *CreateBucketInput) SetListDuringOutage(v bool) *CreateBucketInput {
+	s.ListDuringOutage = &v
+	return s
+}
+
 // SetMetadataSearch sets the MetadataSearch field's value.
 func (s *CreateBucketInput) SetMetadataSearch(v string) *CreateBucketInput {
 	s.MetadataSearch = &v
 	return s
 }
 
+// SetMetadataSearchIndex sets the MetadataSearch field's value from a
+// structured index definition. A nil index clears the field.
+func (s *CreateBucketInput) SetMetadataSearchIndex(v *MetadataSearchIndex) *CreateBucketInput {
+	if v == nil {
+		s.MetadataSearch = nil
+		return s
+	}
+	return s.SetMetadataSearch(v.String())
+}
+
 // SetNameSpace sets the NameSpace field's value.
 func (s *CreateBucketInput) SetNameSpace(v string) *CreateBucketInput {
 	s.NameSpace = &v
@@ -540,6 +802,12 @@ func (s *CreateBucketInput) SetSSEEnabled(v bool) *CreateBucketInput {
 	return s
 }
 
+// SetTSOReadOnly sets the TSOReadOnly field's value.
+func (s *CreateBucketInput) SetTSOReadOnly(v bool) *CreateBucketInput {
+	s.TSOReadOnly = &v
+	return s
+}
+
 // SetVPool sets the VPool field's value.
 func (s *CreateBucketInput) SetVPool(v string) *CreateBucketInput {
 	s.VPool = &v
@@ -740,6 +1008,143 @@ func (s *EcsQueryMetadata) SetMetadataType(v string) *EcsQueryMetadata {
 	return s
 }
 
+type GetBucketIsStaleAllowedInput struct {
+	_ struct{} `type:"structure"`
+
+	// Bucket is a required field
+	Bucket *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
+}
+
+// String returns the string representation
+func (s GetBucketIsStaleAllowedInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s GetBucketIsStaleAllowedInput) GoString() string {
+	return s.String()
+}
+
+// Validate inspects the fields of the type to determine if they are valid.
+func (s *GetBucketIsStaleAllowedInput) Validate() error {
+	invalidParams := request.ErrInvalidParams{Context: "GetBucketIsStaleAllowedInput"}
+	if s.Bucket == nil {
+		invalidParams.Add(request.NewErrParamRequired("Bucket"))
+	}
+
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// SetBucket sets the Bucket field's value.
+func (s *GetBucketIsStaleAllowedInput) SetBucket(v string) *GetBucketIsStaleAllowedInput {
+	s.Bucket = &v
+	return s
+}
+
+type GetBucketIsStaleAllowedOutput struct {
+	_ struct{} `type:"structure"`
+
+	IsStaleAllowed   *bool `location:"header" locationName:"x-emc-is-stale-allowed" type:"boolean"`
+	ListDuringOutage *bool `location:"header" locationName:"x-emc-list-during-outage" type:"boolean"`
+	TSOReadOnly      *bool `location:"header" locationName:"x-emc-tso-read-only" type:"boolean"`
+}
+
+// String returns the string representation
+func (s GetBucketIsStaleAllowedOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s GetBucketIsStaleAllowedOutput) GoString() string {
+	return s.String()
+}
+
+// SetIsStaleAllowed sets the IsStaleAllowed field's value.
+func (s *GetBucketIsStaleAllowedOutput) SetIsStaleAllowed(v bool) *GetBucketIsStaleAllowedOutput {
+	s.IsStaleAllowed = &v
+	return s
+}
+
+// SetListDuringOutage sets the ListDuringOutage field's value.
+func (s *GetBucketIsStaleAllowedOutput) SetListDuringOutage(v bool) *GetBucketIsStaleAllowedOutput {
+	s.ListDuringOutage = &v
+	return s
+}
+
+// SetTSOReadOnly sets the TSOReadOnly field's value.
+func (s *GetBucketIsStaleAllowedOutput) SetTSOReadOnly(v bool) *GetBucketIsStaleAllowedOutput {
+	s.TSOReadOnly = &v
+	return s
+}
+
+type GetBucketRetentionInput struct {
+	_ struct{} `type:"structure"`
+
+	// Bucket is a required field
+	Bucket *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
+}
+
+// String returns the string representation
+func (s GetBucketRetentionInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s GetBucketRetentionInput) GoString() string {
+	return s.String()
+}
+
+// Validate inspects the fields of the type to determine if they are valid.
+func (s *GetBucketRetentionInput) Validate() error {
+	invalidParams := request.ErrInvalidParams{Context: "GetBucketRetentionInput"}
+	if s.Bucket == nil {
+		invalidParams.Add(request.NewErrParamRequired("Bucket"))
+	}
+
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// SetBucket sets the Bucket field's value.
+func (s *GetBucketRetentionInput) SetBucket(v string) *GetBucketRetentionInput {
+	s.Bucket = &v
+	return s
+}
+
+type GetBucketRetentionOutput struct {
+	_ struct{} `type:"structure"`
+
+	ComplianceEnabled *bool  `location:"header" locationName:"x-emc-compliance-enabled" type:"boolean"`
+	RetentionPeriod   *int64 `location:"header" locationName:"x-emc-retention-period" type:"integer"`
+}
+
+// String returns the string representation
+func (s GetBucketRetentionOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s GetBucketRetentionOutput) GoString() string {
+	return s.String()
+}
+
+// SetComplianceEnabled sets the ComplianceEnabled field's value.
+func (s *GetBucketRetentionOutput) SetComplianceEnabled(v bool) *GetBucketRetentionOutput {
+	s.ComplianceEnabled = &v
+	return s
+}
+
+// SetRetentionPeriod sets the RetentionPeriod field's value.
+func (s *GetBucketRetentionOutput) SetRetentionPeriod(v int64) *GetBucketRetentionOutput {
+	s.RetentionPeriod = &v
+	return s
+}
+
 type GetObjectOutput struct {
 	_ struct{} `type:"structure" payload:"Body"`
 
@@ -776,6 +1181,10 @@ type GetObjectOutput struct {
 	Expiration *string `location:"header" locationName:"x-amz-expiration" type:"string"`
 	// The date and time at which the object is no longer cacheable.
 	Expires *string `location:"header" locationName:"Expires" type:"string"`
+	// Set by ECS when the object was read from a site that could not reach
+	// the site owning it, so that the data may be out of date. Only buckets
+	// with IsStaleAllowed serve such reads.
+	IsStale *bool `location:"header" locationName:"x-emc-is-stale" type:"boolean"`
 	// Last modified date of the object
 	LastModified *time.Time `location:"header" locationName:"Last-Modified" type:"timestamp" timestampFormat:"rfc822"`
 	// A map of metadata to store with the object in S3.
@@ -915,6 +1324,12 @@ func (s *GetObjectOutput) SetExpires(v string) *GetObjectOutput {
 	return s
 }
 
+// SetIsStale sets the IsStale field's value.
+func (s *GetObjectOutput) SetIsStale(v bool) *GetObjectOutput {
+	s.IsStale = &v
+	return s
+}
+
 // SetLastModified sets the LastModified field's value.
 func (s *GetObjectOutput) SetLastModified(v time.Time) *GetObjectOutput {
 	s.LastModified = &v
@@ -951,69 +1366,164 @@ func (s *GetObjectOutput) SetRequestCharged(v string) *GetObjectOutput {
 	return s
 }
 
-// SetRestore sets the Restore field's value.
-func (s *GetObjectOutput) SetRestore(v string) *GetObjectOutput {
-	s.Restore = &v
-	return s
+// SetRestore sets the Restore field's value.
+func (s *GetObjectOutput) SetRestore(v string) *GetObjectOutput {
+	s.Restore = &v
+	return s
+}
+
+// SetRetentionPeriod sets the RetentionPeriod field's value.
+func (s *GetObjectOutput) SetRetentionPeriod(v int64) *GetObjectOutput {
+	s.RetentionPeriod = &v
+	return s
+}
+
+// SetRetentionPolicy sets the RetentionPolicy field's value.
+func (s *GetObjectOutput) SetRetentionPolicy(v string) *GetObjectOutput {
+	s.RetentionPolicy = &v
+	return s
+}
+
+// SetSSECustomerAlgorithm sets the SSECustomerAlgorithm field's value.
+func (s *GetObjectOutput) SetSSECustomerAlgorithm(v string) *GetObjectOutput {
+	s.SSECustomerAlgorithm = &v
+	return s
+}
+
+// SetSSECustomerKeyMD5 sets the SSECustomerKeyMD5 field's value.
+func (s *GetObjectOutput) SetSSECustomerKeyMD5(v string) *GetObjectOutput {
+	s.SSECustomerKeyMD5 = &v
+	return s
+}
+
+// SetSSEKMSKeyId sets the SSEKMSKeyId field's value.
+func (s *GetObjectOutput) SetSSEKMSKeyId(v string) *GetObjectOutput {
+	s.SSEKMSKeyId = &v
+	return s
+}
+
+// SetServerSideEncryption sets the ServerSideEncryption field's value.
+func (s *GetObjectOutput) SetServerSideEncryption(v string) *GetObjectOutput {
+	s.ServerSideEncryption = &v
+	return s
+}
+
+// SetStorageClass sets the StorageClass field's value.
+func (s *GetObjectOutput) SetStorageClass(v string) *GetObjectOutput {
+	s.StorageClass = &v
+	return s
+}
+
+// SetTagCount sets the TagCount field's value.
+func (s *GetObjectOutput) SetTagCount(v int64) *GetObjectOutput {
+	s.TagCount = &v
+	return s
+}
+
+// SetVersionId sets the VersionId field's value.
+func (s *GetObjectOutput) SetVersionId(v string) *GetObjectOutput {
+	s.VersionId = &v
+	return s
+}
+
+// SetWebsiteRedirectLocation sets the WebsiteRedirectLocation field's value.
+func (s *GetObjectOutput) SetWebsiteRedirectLocation(v string) *GetObjectOutput {
+	s.WebsiteRedirectLocation = &v
+	return s
+}
+
+type GetObjectRetentionInput struct {
+	_ struct{} `type:"structure"`
+
+	// Bucket is a required field
+	Bucket *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
+	// Key is a required field
+	Key *string `location:"uri" locationName:"Key" min:"1" type:"string" required:"true"`
+	// VersionId used to reference a specific version of the object.
+	VersionId *string `location:"querystring" locationName:"versionId" type:"string"`
+}
+
+// String returns the string representation
+func (s GetObjectRetentionInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s GetObjectRetentionInput) GoString() string {
+	return s.String()
+}
+
+// Validate inspects the fields of the type to determine if they are valid.
+func (s *GetObjectRetentionInput) Validate() error {
+	invalidParams := request.ErrInvalidParams{Context: "GetObjectRetentionInput"}
+	if s.Bucket == nil {
+		invalidParams.Add(request.NewErrParamRequired("Bucket"))
+	}
+	if s.Key == nil {
+		invalidParams.Add(request.NewErrParamRequired("Key"))
+	}
+	if s.Key != nil && len(*s.Key) < 1 {
+		invalidParams.Add(request.NewErrParamMinLen("Key", 1))
+	}
+
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
 }
 
-// SetRetentionPeriod sets the RetentionPeriod field's value.
-func (s *GetObjectOutput) SetRetentionPeriod(v int64) *GetObjectOutput {
-	s.RetentionPeriod = &v
+// SetBucket sets the Bucket field's value.
+func (s *GetObjectRetentionInput) SetBucket(v string) *GetObjectRetentionInput {
+	s.Bucket = &v
 	return s
 }
 
-// SetRetentionPolicy sets the RetentionPolicy field's value.
-func (s *GetObjectOutput) SetRetentionPolicy(v string) *GetObjectOutput {
-	s.RetentionPolicy = &v
+// SetKey sets the Key field's value.
+func (s *GetObjectRetentionInput) SetKey(v string) *GetObjectRetentionInput {
+	s.Key = &v
 	return s
 }
 
-// SetSSECustomerAlgorithm sets the SSECustomerAlgorithm field's value.
-func (s *GetObjectOutput) SetSSECustomerAlgorithm(v string) *GetObjectOutput {
-	s.SSECustomerAlgorithm = &v
+// SetVersionId sets the VersionId field's value.
+func (s *GetObjectRetentionInput) SetVersionId(v string) *GetObjectRetentionInput {
+	s.VersionId = &v
 	return s
 }
 
-// SetSSECustomerKeyMD5 sets the SSECustomerKeyMD5 field's value.
-func (s *GetObjectOutput) SetSSECustomerKeyMD5(v string) *GetObjectOutput {
-	s.SSECustomerKeyMD5 = &v
-	return s
-}
+type GetObjectRetentionOutput struct {
+	_ struct{} `type:"structure"`
 
-// SetSSEKMSKeyId sets the SSEKMSKeyId field's value.
-func (s *GetObjectOutput) SetSSEKMSKeyId(v string) *GetObjectOutput {
-	s.SSEKMSKeyId = &v
-	return s
+	// Last modified date of the object
+	LastModified    *time.Time `location:"header" locationName:"Last-Modified" type:"timestamp" timestampFormat:"rfc822"`
+	RetentionPeriod *int64     `location:"header" locationName:"x-emc-retention-period" type:"integer"`
+	RetentionPolicy *string    `location:"header" locationName:"x-emc-retention-policy" type:"string"`
 }
 
-// SetServerSideEncryption sets the ServerSideEncryption field's value.
-func (s *GetObjectOutput) SetServerSideEncryption(v string) *GetObjectOutput {
-	s.ServerSideEncryption = &v
-	return s
+// String returns the string representation
+func (s GetObjectRetentionOutput) String() string {
+	return awsutil.Prettify(s)
 }
 
-// SetStorageClass sets the StorageClass field's value.
-func (s *GetObjectOutput) SetStorageClass(v string) *GetObjectOutput {
-	s.StorageClass = &v
-	return s
+// GoString returns the string representation
+func (s GetObjectRetentionOutput) GoString() string {
+	return s.String()
 }
 
-// SetTagCount sets the TagCount field's value.
-func (s *GetObjectOutput) SetTagCount(v int64) *GetObjectOutput {
-	s.TagCount = &v
+// SetLastModified sets the LastModified field's value.
+func (s *GetObjectRetentionOutput) SetLastModified(v time.Time) *GetObjectRetentionOutput {
+	s.LastModified = &v
 	return s
 }
 
-// SetVersionId sets the VersionId field's value.
-func (s *GetObjectOutput) SetVersionId(v string) *GetObjectOutput {
-	s.VersionId = &v
+// SetRetentionPeriod sets the RetentionPeriod field's value.
+func (s *GetObjectRetentionOutput) SetRetentionPeriod(v int64) *GetObjectRetentionOutput {
+	s.RetentionPeriod = &v
 	return s
 }
 
-// SetWebsiteRedirectLocation sets the WebsiteRedirectLocation field's value.
-func (s *GetObjectOutput) SetWebsiteRedirectLocation(v string) *GetObjectOutput {
-	s.WebsiteRedirectLocation = &v
+// SetRetentionPolicy sets the RetentionPolicy field's value.
+func (s *GetObjectRetentionOutput) SetRetentionPolicy(v string) *GetObjectRetentionOutput {
+	s.RetentionPolicy = &v
 	return s
 }
 
@@ -1063,7 +1573,16 @@ func (s *GetSystemMetadataSearchKeysOutput) SetOptionalAttributes(v []*EcsOption
 type HeadBucketOutput struct {
 	_ struct{} `type:"structure"`
 
-	RetentionPeriod *int64 `location:"header" locationName:"x-emc-retention-period" type:"integer"`
+	ComplianceEnabled *bool   `location:"header" locationName:"x-emc-compliance-enabled" type:"boolean"`
+	FileSystemAccess  *bool   `location:"header" locationName:"x-emc-file-system-access-enabled" type:"boolean"`
+	IsStaleAllowed    *bool   `location:"header" locationName:"x-emc-is-stale-allowed" type:"boolean"`
+	ListDuringOutage  *bool   `location:"header" locationName:"x-emc-list-during-outage" type:"boolean"`
+	MetadataSearch    *string `location:"header" locationName:"x-emc-metadata-search" type:"string"`
+	NameSpace         *string `location:"header" locationName:"x-emc-namespace" type:"string"`
+	RetentionPeriod   *int64  `location:"header" locationName:"x-emc-retention-period" type:"integer"`
+	SSEEnabled        *bool   `location:"header" locationName:"x-emc-server-side-encryption-enabled" type:"boolean"`
+	TSOReadOnly       *bool   `location:"header" locationName:"x-emc-tso-read-only" type:"boolean"`
+	VPool             *string `location:"header" locationName:"x-emc-vpool" type:"string"`
 }
 
 // String returns the string representation
@@ -1076,12 +1595,66 @@ func (s HeadBucketOutput) GoString() string {
 	return s.String()
 }
 
+// SetComplianceEnabled sets the ComplianceEnabled field's value.
+func (s *HeadBucketOutput) SetComplianceEnabled(v bool) *HeadBucketOutput {
+	s.ComplianceEnabled = &v
+	return s
+}
+
+// SetFileSystemAccess sets the FileSystemAccess field's value.
+func (s *HeadBucketOutput) SetFileSystemAccess(v bool) *HeadBucketOutput {
+	s.FileSystemAccess = &v
+	return s
+}
+
+// SetIsStaleAllowed sets the IsStaleAllowed field's value.
+func (s *HeadBucketOutput) SetIsStaleAllowed(v bool) *HeadBucketOutput {
+	s.IsStaleAllowed = &v
+	return s
+}
+
+// SetListDuringOutage sets the ListDuringOutage field's value.
+func (s *HeadBucketOutput) SetListDuringOutage(v bool) *HeadBucketOutput {
+	s.ListDuringOutage = &v
+	return s
+}
+
+// SetMetadataSearch sets the MetadataSearch field's value.
+func (s *HeadBucketOutput) SetMetadataSearch(v string) *HeadBucketOutput {
+	s.MetadataSearch = &v
+	return s
+}
+
+// SetNameSpace sets the NameSpace field's value.
+func (s *HeadBucketOutput) SetNameSpace(v string) *HeadBucketOutput {
+	s.NameSpace = &v
+	return s
+}
+
 // SetRetentionPeriod sets the RetentionPeriod field's value.
 func (s *HeadBucketOutput) SetRetentionPeriod(v int64) *HeadBucketOutput {
 	s.RetentionPeriod = &v
 	return s
 }
 
+// SetSSEEnabled sets the SSEEnabled field's value.
+func (s *HeadBucketOutput) SetSSEEnabled(v bool) *HeadBucketOutput {
+	s.SSEEnabled = &v
+	return s
+}
+
+// SetTSOReadOnly sets the TSOReadOnly field's value.
+func (s *HeadBucketOutput) SetTSOReadOnly(v bool) *HeadBucketOutput {
+	s.TSOReadOnly = &v
+	return s
+}
+
+// SetVPool sets the VPool field's value.
+func (s *HeadBucketOutput) SetVPool(v string) *HeadBucketOutput {
+	s.VPool = &v
+	return s
+}
+
 type HeadObjectOutput struct {
 	_ struct{} `type:"structure"`
 
@@ -1097,7 +1670,8 @@ type HeadObjectOutput struct {
 	// The language the content is in.
 	ContentLanguage *string `location:"header" locationName:"Content-Language" type:"string"`
 	// Size of the body in bytes.
-	ContentLength *int64 `location:"header" locationName:"Content-Length" type:"long"`
+	ContentLength *int64  `location:"header" locationName:"Content-Length" type:"long"`
+	ContentMD5EMC *string `location:"header" locationName:"x-emc-content-md5" type:"string"`
 	// A standard MIME type describing the format of the object data.
 	ContentType *string `location:"header" locationName:"Content-Type" type:"string"`
 	// Specifies whether the object retrieved was (true) or was not (false) a Delete
@@ -1113,6 +1687,10 @@ type HeadObjectOutput struct {
 	Expiration *string `location:"header" locationName:"x-amz-expiration" type:"string"`
 	// The date and time at which the object is no longer cacheable.
 	Expires *string `location:"header" locationName:"Expires" type:"string"`
+	// Set by ECS when the object was read from a site that could not reach
+	// the site owning it, so that the data may be out of date. Only buckets
+	// with IsStaleAllowed serve such reads.
+	IsStale *bool `location:"header" locationName:"x-emc-is-stale" type:"boolean"`
 	// Last modified date of the object
 	LastModified *time.Time `location:"header" locationName:"Last-Modified" type:"timestamp" timestampFormat:"rfc822"`
 	// A map of metadata to store with the object in S3.
@@ -1202,6 +1780,12 @@ func (s *HeadObjectOutput) SetContentLength(v int64) *HeadObjectOutput {
 	return s
 }
 
+// SetContentMD5EMC sets the ContentMD5EMC field's value.
+func (s *HeadObjectOutput) SetContentMD5EMC(v string) *HeadObjectOutput {
+	s.ContentMD5EMC = &v
+	return s
+}
+
 // SetContentType sets the ContentType field's value.
 func (s *HeadObjectOutput) SetContentType(v string) *HeadObjectOutput {
 	s.ContentType = &v
@@ -1232,6 +1816,12 @@ func (s *HeadObjectOutput) SetExpires(v string) *HeadObjectOutput {
 	return s
 }
 
+// SetIsStale sets the IsStale field's value.
+func (s *HeadObjectOutput) SetIsStale(v bool) *HeadObjectOutput {
+	s.IsStale = &v
+	return s
+}
+
 // SetLastModified sets the LastModified field's value.
 func (s *HeadObjectOutput) SetLastModified(v time.Time) *HeadObjectOutput {
 	s.LastModified = &v
@@ -1400,6 +1990,11 @@ func (s *ListBucketMetadataSearchOutput) SetOptionalAttributes(v []*EcsOptionalA
 	return s
 }
 
+// MetadataSearchIndex returns the IndexableKeys as a MetadataSearchIndex.
+func (s *ListBucketMetadataSearchOutput) MetadataSearchIndex() *MetadataSearchIndex {
+	return NewMetadataSearchIndex(s.IndexableKeys)
+}
+
 type ListBucketQueryInput struct {
 	_ struct{} `type:"structure"`
 
@@ -1485,8 +2080,9 @@ func (s *ListBucketQueryInput) SetSorted(v string) *ListBucketQueryInput {
 type ListBucketQueryOutput struct {
 	_ struct{} `type:"structure"`
 
-	MaxKeys       *int64            `type:"integer"`
-	Name          *string           `type:"string"`
+	MaxKeys *int64  `type:"integer"`
+	Name    *string `type:"string"`
+	// Marker of the next page of results, nil on the last page.
 	NextMarker    *string           `type:"string"`
 	ObjectMatches []*EcsObjectMatch `locationNameList:"object" type:"list"`
 }
@@ -1529,8 +2125,10 @@ type PutBucketIsStaleAllowedInput struct {
 	_ struct{} `type:"structure"`
 
 	// Bucket is a required field
-	Bucket         *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
-	IsStaleAllowed *bool   `location:"header" locationName:"x-emc-is-stale-allowed" type:"boolean"`
+	Bucket           *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
+	IsStaleAllowed   *bool   `location:"header" locationName:"x-emc-is-stale-allowed" type:"boolean"`
+	ListDuringOutage *bool   `location:"header" locationName:"x-emc-list-during-outage" type:"boolean"`
+	TSOReadOnly      *bool   `location:"header" locationName:"x-emc-tso-read-only" type:"boolean"`
 }
 
 // String returns the string representation
@@ -1568,6 +2166,18 @@ func (s *PutBucketIsStaleAllowedInput) SetIsStaleAllowed(v bool) *PutBucketIsSta
 	return s
 }
 
+// SetListDuringOutage sets the ListDuringOutage field's value.
+func (s *PutBucketIsStaleAllowedInput) SetListDuringOutage(v bool) *PutBucketIsStaleAllowedInput {
+	s.ListDuringOutage = &v
+	return s
+}
+
+// SetTSOReadOnly sets the TSOReadOnly field's value.
+func (s *PutBucketIsStaleAllowedInput) SetTSOReadOnly(v bool) *PutBucketIsStaleAllowedInput {
+	s.TSOReadOnly = &v
+	return s
+}
+
 type PutBucketIsStaleAllowedOutput struct {
 	_ struct{} `type:"structure"`
 }
@@ -1582,6 +2192,70 @@ func (s PutBucketIsStaleAllowedOutput) GoString() string {
 	return s.String()
 }
 
+type PutBucketRetentionInput struct {
+	_ struct{} `type:"structure"`
+
+	// Bucket is a required field
+	Bucket *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
+	// RetentionPeriod is a required field
+	RetentionPeriod *int64 `location:"header" locationName:"x-emc-retention-period" type:"integer" required:"true"`
+}
+
+// String returns the string representation
+func (s PutBucketRetentionInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s PutBucketRetentionInput) GoString() string {
+	return s.String()
+}
+
+// Validate inspects the fields of the type to determine if they are valid.
+func (s *PutBucketRetentionInput) Validate() error {
+	invalidParams := request.ErrInvalidParams{Context: "PutBucketRetentionInput"}
+	if s.Bucket == nil {
+		invalidParams.Add(request.NewErrParamRequired("Bucket"))
+	}
+	if s.RetentionPeriod == nil {
+		invalidParams.Add(request.NewErrParamRequired("RetentionPeriod"))
+	}
+	if s.RetentionPeriod != nil && *s.RetentionPeriod < 0 {
+		invalidParams.Add(request.NewErrParamMinValue("RetentionPeriod", 0))
+	}
+
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// SetBucket sets the Bucket field's value.
+func (s *PutBucketRetentionInput) SetBucket(v string) *PutBucketRetentionInput {
+	s.Bucket = &v
+	return s
+}
+
+// SetRetentionPeriod sets the RetentionPeriod field's value.
+func (s *PutBucketRetentionInput) SetRetentionPeriod(v int64) *PutBucketRetentionInput {
+	s.RetentionPeriod = &v
+	return s
+}
+
+type PutBucketRetentionOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// String returns the string representation
+func (s PutBucketRetentionOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s PutBucketRetentionOutput) GoString() string {
+	return s.String()
+}
+
 type PutObjectInput struct {
 	_ struct{} `type:"structure" payload:"Body"`
 
@@ -1867,6 +2541,8 @@ func (s *PutObjectInput) SetWebsiteRedirectLocation(v string) *PutObjectInput {
 type PutObjectOutput struct {
 	_ struct{} `type:"structure"`
 
+	// Offset at which the data of an append was written.
+	AppendOffset  *int64  `location:"header" locationName:"x-emc-append-offset" type:"integer"`
 	ContentMD5EMC *string `location:"header" locationName:"x-emc-content-md5" type:"string"`
 	// Entity tag for the uploaded object.
 	ETag *string `location:"header" locationName:"ETag" type:"string"`
@@ -1905,6 +2581,12 @@ func (s PutObjectOutput) GoString() string {
 	return s.String()
 }
 
+// SetAppendOffset sets the AppendOffset field's value.
+func (s *PutObjectOutput) SetAppendOffset(v int64) *PutObjectOutput {
+	s.AppendOffset = &v
+	return s
+}
+
 // SetContentMD5EMC sets the ContentMD5EMC field's value.
 func (s *PutObjectOutput) SetContentMD5EMC(v string) *PutObjectOutput {
 	s.ContentMD5EMC = &v
@@ -1965,6 +2647,112 @@ func (s *PutObjectOutput) SetVersionId(v string) *PutObjectOutput {
 	return s
 }
 
+type PutObjectRetentionInput struct {
+	_ struct{} `type:"structure"`
+
+	// Bucket is a required field
+	Bucket *string `location:"uri" locationName:"Bucket" type:"string" required:"true"`
+	// Key is a required field
+	Key             *string `location:"uri" locationName:"Key" min:"1" type:"string" required:"true"`
+	RetentionPeriod *int64  `location:"header" locationName:"x-emc-retention-period" type:"integer"`
+	RetentionPolicy *string `location:"header" locationName:"x-emc-retention-policy" type:"string"`
+	// VersionId used to reference a specific version of the object.
+	VersionId *string `location:"querystring" locationName:"versionId" type:"string"`
+}
+
+// String returns the string representation
+func (s PutObjectRetentionInput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s PutObjectRetentionInput) GoString() string {
+	return s.String()
+}
+
+// Validate inspects the fields of the type to determine if they are valid.
+func (s *PutObjectRetentionInput) Validate() error {
+	invalidParams := request.ErrInvalidParams{Context: "PutObjectRetentionInput"}
+	if s.Bucket == nil {
+		invalidParams.Add(request.NewErrParamRequired("Bucket"))
+	}
+	if s.Key == nil {
+		invalidParams.Add(request.NewErrParamRequired("Key"))
+	}
+	if s.Key != nil && len(*s.Key) < 1 {
+		invalidParams.Add(request.NewErrParamMinLen("Key", 1))
+	}
+	if s.RetentionPeriod == nil && s.RetentionPolicy == nil {
+		invalidParams.Add(request.NewErrParamRequired("RetentionPeriod"))
+	}
+	if s.RetentionPeriod != nil && *s.RetentionPeriod < 0 {
+		invalidParams.Add(request.NewErrParamMinValue("RetentionPeriod", 0))
+	}
+
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// SetBucket sets the Bucket field's value.
+func (s *PutObjectRetentionInput) SetBucket(v string) *PutObjectRetentionInput {
+	s.Bucket = &v
+	return s
+}
+
+// SetKey sets the Key field's value.
+func (s *PutObjectRetentionInput) SetKey(v string) *PutObjectRetentionInput {
+	s.Key = &v
+	return s
+}
+
+// SetRetentionPeriod sets the RetentionPeriod field's value.
+func (s *PutObjectRetentionInput) SetRetentionPeriod(v int64) *PutObjectRetentionInput {
+	s.RetentionPeriod = &v
+	return s
+}
+
+// SetRetentionPolicy sets the RetentionPolicy field's value.
+func (s *PutObjectRetentionInput) SetRetentionPolicy(v string) *PutObjectRetentionInput {
+	s.RetentionPolicy = &v
+	return s
+}
+
+// SetVersionId sets the VersionId field's value.
+func (s *PutObjectRetentionInput) SetVersionId(v string) *PutObjectRetentionInput {
+	s.VersionId = &v
+	return s
+}
+
+type PutObjectRetentionOutput struct {
+	_ struct{} `type:"structure"`
+}
+
+// String returns the string representation
+func (s PutObjectRetentionOutput) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s PutObjectRetentionOutput) GoString() string {
+	return s.String()
+}
+
+const (
+	// DatatypeString is a Datatype enum value
+	DatatypeString = "String"
+
+	// DatatypeInteger is a Datatype enum value
+	DatatypeInteger = "Integer"
+
+	// DatatypeDecimal is a Datatype enum value
+	DatatypeDecimal = "Decimal"
+
+	// DatatypeDatetime is a Datatype enum value
+	DatatypeDatetime = "Datetime"
+)
+
 func defaultInitRequestFn(r *request.Request) {
 	platformRequestHandlers(r)
 	if r.Operation.Name == opCreateBucket {
@@ -1975,21 +2763,35 @@ func defaultInitRequestFn(r *request.Request) {
 func platformRequestHandlers(r *request.Request) {
 	if r.Operation.HTTPMethod == "PUT" {
 		// 100-Continue should only be used on put requests.
-		r.Handlers.Sign.PushBack(add100Continue)
+		r.Handlers.Sign.RemoveByName(continueHandlerName)
+		r.Handlers.Sign.PushBackNamed(add100ContinueHandler)
 	}
 }
 
+var add100ContinueHandler = request.NamedHandler{Name: continueHandlerName, Fn: add100Continue}
+
 func add100Continue(r *request.Request) {
 	if aws.BoolValue(r.Config.S3Disable100Continue) {
 		return
 	}
-	if r.HTTPRequest.ContentLength < 1024*1024*2 {
-		// Ignore requests smaller than 2MB. This helps prevent delaying
-		// requests unnecessarily.
+	// Ignore requests smaller than 2MB. This helps prevent delaying
+	// requests unnecessarily.
+	set100Continue(r, DefaultContinueThreshold)
+}
+
+// lastPageMarker is the NextMarker ECS returns on the last page of a query.
+const lastPageMarker = "NO MORE PAGES"
+
+// clearLastPageMarker unsets NextMarker on the last page of a query so that
+// paginators stop there.
+func clearLastPageMarker(r *request.Request) {
+	if r.Error != nil {
 		return
 	}
-
-	r.HTTPRequest.Header.Set("Expect", "100-Continue")
+	out := r.Data.(*ListBucketQueryOutput)
+	if m := aws.StringValue(out.NextMarker); m == "" || m == lastPageMarker {
+		out.NextMarker = nil
+	}
 }
 
 func populateLocationConstraint(r *request.Request) {
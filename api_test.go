@@ -16,6 +16,7 @@ package ecs_test
  */
 
 import (
+	"os"
 	"strconv"
 	"strings"
 	"testing"
@@ -35,6 +36,16 @@ func init() {
 	config := unit.LoadConfig()
 	myBucket = config.GetString("s3.test_bucket_name")
 	myKey = config.GetString("s3.test_key_name")
+	if !unit.Configured(config) {
+		myBucket, myKey = "test-bucket", "test-key"
+	}
+}
+
+func TestMain(m *testing.M) {
+	close := unit.Open()
+	code := m.Run()
+	close()
+	os.Exit(code)
 }
 
 func TestBucketExtension(t *testing.T) {
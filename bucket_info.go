@@ -0,0 +1,101 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awsutil"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// BucketInfo describes the ECS properties of a bucket. Fields the server did
+// not report are nil.
+type BucketInfo struct {
+	Bucket            *string
+	ComplianceEnabled *bool
+	FileSystemAccess  *bool
+	IsStaleAllowed    *bool
+	ListDuringOutage  *bool
+	// Keys indexed for metadata search, nil if metadata search is disabled.
+	MetadataSearchIndex *MetadataSearchIndex
+	NameSpace           *string
+	RetentionPeriod     *int64
+	SSEEnabled          *bool
+	TSOReadOnly         *bool
+	VPool               *string
+}
+
+// String returns the string representation
+func (s BucketInfo) String() string {
+	return awsutil.Prettify(s)
+}
+
+// GoString returns the string representation
+func (s BucketInfo) GoString() string {
+	return s.String()
+}
+
+// MetadataSearchEnabled reports whether the bucket indexes keys for metadata
+// search.
+func (s *BucketInfo) MetadataSearchEnabled() bool {
+	return s.MetadataSearchIndex != nil
+}
+
+// GetBucketInfo returns every ECS property of bucket. The properties are read
+// from the HEAD bucket response; the metadata search index is read from the
+// searchmetadata sub-resource when the HEAD response does not carry it.
+func (c *S3) GetBucketInfo(bucket string) (*BucketInfo, error) {
+	return c.GetBucketInfoWithContext(aws.BackgroundContext(), bucket)
+}
+
+// GetBucketInfoWithContext is the same as GetBucketInfo with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) GetBucketInfoWithContext(ctx aws.Context, bucket string, opts ...request.Option) (*BucketInfo, error) {
+	head, err := c.HeadBucketExtensionWithContext(ctx, &s3.HeadBucketInput{Bucket: aws.String(bucket)}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &BucketInfo{
+		Bucket:            aws.String(bucket),
+		ComplianceEnabled: head.ComplianceEnabled,
+		FileSystemAccess:  head.FileSystemAccess,
+		IsStaleAllowed:    head.IsStaleAllowed,
+		ListDuringOutage:  head.ListDuringOutage,
+		NameSpace:         head.NameSpace,
+		RetentionPeriod:   head.RetentionPeriod,
+		SSEEnabled:        head.SSEEnabled,
+		TSOReadOnly:       head.TSOReadOnly,
+		VPool:             head.VPool,
+	}
+
+	if head.MetadataSearch != nil {
+		if info.MetadataSearchIndex, err = ParseMetadataSearchIndex(*head.MetadataSearch); err != nil {
+			return nil, err
+		}
+		return info, nil
+	}
+
+	search, err := c.ListBucketMetadataSearchWithContext(ctx, &ListBucketMetadataSearchInput{Bucket: aws.String(bucket)}, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if aws.BoolValue(search.MetadataSearchEnabled) {
+		info.MetadataSearchIndex = search.MetadataSearchIndex()
+	}
+	return info, nil
+}
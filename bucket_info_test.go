@@ -0,0 +1,61 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetBucketInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("x-emc-compliance-enabled", "true")
+			w.Header().Set("x-emc-file-system-access-enabled", "false")
+			w.Header().Set("x-emc-is-stale-allowed", "true")
+			w.Header().Set("x-emc-namespace", "ns1")
+			w.Header().Set("x-emc-retention-period", "60")
+			w.Header().Set("x-emc-vpool", "rg1")
+			return
+		}
+		_, ok := r.URL.Query()["searchmetadata"]
+		assert.True(t, ok)
+		w.Write([]byte(`<MetadataSearchList>
+  <MetadataSearchEnabled>true</MetadataSearchEnabled>
+  <IndexableKeys>
+    <Key><Name>Size</Name><Datatype>integer</Datatype></Key>
+    <Key><Name>x-amz-meta-STR</Name><Datatype>string</Datatype></Key>
+  </IndexableKeys>
+</MetadataSearchList>`))
+	}))
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	info, err := client.GetBucketInfo("bucket")
+	assert.Nil(t, err)
+	assert.True(t, *info.ComplianceEnabled)
+	assert.False(t, *info.FileSystemAccess)
+	assert.True(t, *info.IsStaleAllowed)
+	assert.Equal(t, "ns1", *info.NameSpace)
+	assert.Equal(t, int64(60), *info.RetentionPeriod)
+	assert.Equal(t, "rg1", *info.VPool)
+	assert.Nil(t, info.SSEEnabled)
+	assert.True(t, info.MetadataSearchEnabled())
+	assert.Equal(t, "Size,x-amz-meta-STR;String", info.MetadataSearchIndex.String())
+}
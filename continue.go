@@ -0,0 +1,62 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import "github.com/aws/aws-sdk-go/aws/request"
+
+// Thresholds for WithContinueThreshold.
+const (
+	// DefaultContinueThreshold is the smallest PUT body sent with
+	// "Expect: 100-Continue" unless configured otherwise.
+	DefaultContinueThreshold int64 = 2 * 1024 * 1024
+
+	// ContinueAlways sends every PUT with a body with "Expect: 100-Continue".
+	ContinueAlways int64 = 0
+
+	// ContinueNever never sends "Expect: 100-Continue".
+	ContinueNever int64 = -1
+)
+
+const continueHandlerName = "ecs.ContinueHandler"
+
+// WithContinueThreshold returns a request option sending PUT requests whose
+// body is at least threshold bytes with "Expect: 100-Continue", instead of
+// those of at least DefaultContinueThreshold. Use ContinueAlways or
+// ContinueNever to send it with every PUT or with none. The option applies to
+// the PUT operations of the embedded s3.S3 as well as to the ECS ones, and
+// takes precedence over aws.Config.S3Disable100Continue.
+func WithContinueThreshold(threshold int64) request.Option {
+	handler := request.NamedHandler{Name: continueHandlerName, Fn: func(r *request.Request) {
+		set100Continue(r, threshold)
+	}}
+	return requestOption(func(r *request.Request) {
+		r.Handlers.Sign.RemoveByName(continueHandlerName)
+		r.Handlers.Sign.PushBackNamed(handler)
+	})
+}
+
+// set100Continue sets or removes "Expect: 100-Continue" on a PUT request
+// according to threshold. It runs after the s3 handler adding the header.
+func set100Continue(r *request.Request, threshold int64) {
+	if r.Operation.HTTPMethod != "PUT" {
+		return
+	}
+	if threshold >= 0 && r.HTTPRequest.ContentLength > 0 && r.HTTPRequest.ContentLength >= threshold {
+		r.HTTPRequest.Header.Set("Expect", "100-Continue")
+	} else {
+		r.HTTPRequest.Header.Del("Expect")
+	}
+}
@@ -0,0 +1,102 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectHeaders returns the Expect header of each request.
+func expectHeaders(requests []recordedRequest) []string {
+	var expect []string
+	for _, r := range requests {
+		expect = append(expect, r.Header.Get("Expect"))
+	}
+	return expect
+}
+
+func putObject(t *testing.T, c *ecs.S3, size int, opts ...request.Option) {
+	_, err := c.PutObjectWithContext(aws.BackgroundContext(), &s3.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+		Body:   bytes.NewReader(make([]byte, size)),
+	}, opts...)
+	assert.Nil(t, err)
+}
+
+func TestContinueDefault(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	client := newLocalClient(server.URL)
+	disabled := ecs.New(s3.New(newLocalSession(server.URL), &aws.Config{S3Disable100Continue: aws.Bool(true)}))
+
+	size := int(ecs.DefaultContinueThreshold)
+	putObject(t, client, size-1)
+	putObject(t, client, size)
+	_, err := client.PutObjectExtension(&ecs.PutObjectInput{
+		Bucket: aws.String("bucket"),
+		Key:    aws.String("key"),
+		Body:   bytes.NewReader(make([]byte, size)),
+	})
+	assert.Nil(t, err)
+	putObject(t, disabled, size)
+	assert.Equal(t, []string{"", "100-Continue", "100-Continue", ""}, expectHeaders(requests))
+}
+
+func TestWithContinueThreshold(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	client := ecs.NewWithOptions(newLocalSession(server.URL), ecs.WithContinueThreshold(10))
+
+	putObject(t, client, 20)
+	putObject(t, client, 5)
+	putObject(t, client, 20, ecs.WithContinueThreshold(100))
+	putObject(t, client, int(ecs.DefaultContinueThreshold), ecs.WithContinueThreshold(ecs.ContinueNever))
+	// PUTs without a body never wait for 100-Continue.
+	_, err := client.PutBucketIsStaleAllowed(&ecs.PutBucketIsStaleAllowedInput{
+		Bucket:         aws.String("bucket"),
+		IsStaleAllowed: aws.Bool(true),
+	})
+	assert.Nil(t, err)
+	_, err = client.AppendObjectWithContext(aws.BackgroundContext(), "bucket", "key", strings.NewReader("x"), ecs.WithContinueThreshold(ecs.ContinueAlways))
+	assert.Nil(t, err)
+	_, err = client.ListObjects(&s3.ListObjectsInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"100-Continue", "", "", "", "", "100-Continue", ""}, expectHeaders(requests))
+}
+
+func TestWithContinueThresholdOverridesConfig(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	sess := newLocalSession(server.URL).Copy(&aws.Config{S3Disable100Continue: aws.Bool(true)})
+	client := ecs.NewWithOptions(sess, ecs.WithContinueThreshold(ecs.ContinueAlways))
+
+	putObject(t, client, 1)
+	putObject(t, client, 0)
+	assert.Equal(t, []string{"100-Continue", ""}, expectHeaders(requests))
+}
@@ -0,0 +1,365 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Settings of the Downloader returned by NewDownloader.
+const (
+	DefaultDownloadPartSize    int64 = 5 * 1024 * 1024
+	DefaultDownloadConcurrency       = 5
+)
+
+// DownloadAPI is the operation a Downloader reads objects with. It is
+// implemented by S3 and by implementations of ecsiface.ECSAPI.
+type DownloadAPI interface {
+	GetObjectExtensionWithContext(aws.Context, *s3.GetObjectInput, ...request.Option) (*GetObjectOutput, error)
+}
+
+// A Downloader reads objects in byte ranges of PartSize, up to Concurrency at
+// a time, into an io.WriterAt.
+//
+// Every range after the first is read with the ETag of the first in
+// If-Match, so that the download fails with a PreconditionFailed error rather
+// than mixing two objects if the object is overwritten meanwhile. A range
+// that fails is read again on its own, as decided by Retryer. The MD5 of the
+// object read is checked against its x-emc-content-md5. A Downloader may be
+// used concurrently.
+//
+//	downloader := ecs.NewDownloader(client)
+//	out, err := downloader.Download(file, &s3.GetObjectInput{
+//	    Bucket: aws.String("bucket"),
+//	    Key:    aws.String("key"),
+//	})
+type Downloader struct {
+	// PartSize is the size of the ranges read. Each download in progress
+	// buffers up to 2*Concurrency parts.
+	PartSize int64
+	// Concurrency is the number of ranges of a download read at a time.
+	Concurrency int
+	// Retryer decides whether and when a failed range is read again. The
+	// retries come on top of those of the client. Failed ranges are not
+	// retried if Retryer is nil.
+	Retryer *Retryer
+	// RequestOptions apply to every request of the downloads.
+	RequestOptions []request.Option
+
+	S3 DownloadAPI
+}
+
+// NewDownloader returns a Downloader reading objects with client and
+// retrying failed ranges with NewRetryer, with the default settings changed
+// by options.
+func NewDownloader(client DownloadAPI, options ...func(*Downloader)) *Downloader {
+	d := &Downloader{
+		PartSize:    DefaultDownloadPartSize,
+		Concurrency: DefaultDownloadConcurrency,
+		Retryer:     NewRetryer(),
+		S3:          client,
+	}
+	for _, option := range options {
+		option(d)
+	}
+	return d
+}
+
+// Download writes the object described by input to w. Range must not be
+// set. It returns the output of the first range read, without Body and
+// ContentRange, with ContentLength set to the size of the object, so that
+// the ECS fields such as RetentionPeriod, RetentionPolicy and ContentMD5EMC
+// are available.
+//
+// If the object was read but its MD5 does not match its x-emc-content-md5,
+// Download returns the output with an error with the code
+// ErrCodeContentMD5Mismatch. On failure, w may hold part of the object.
+func (d *Downloader) Download(w io.WriterAt, input *s3.GetObjectInput, options ...func(*Downloader)) (*GetObjectOutput, error) {
+	return d.DownloadWithContext(aws.BackgroundContext(), w, input, options...)
+}
+
+// DownloadWithContext is the same as Download with the addition of the
+// ability to pass a context. The download stops when ctx is done.
+func (d *Downloader) DownloadWithContext(ctx aws.Context, w io.WriterAt, input *s3.GetObjectInput, options ...func(*Downloader)) (*GetObjectOutput, error) {
+	settings := *d
+	for _, option := range options {
+		option(&settings)
+	}
+	if err := settings.validate(w, input); err != nil {
+		return nil, err
+	}
+
+	dl := &download{
+		Downloader: &settings,
+		ctx:        ctx,
+		w:          w,
+		input:      *input,
+		hash:       md5.New(),
+		pending:    map[int64][]byte{},
+	}
+	return dl.run()
+}
+
+func (d *Downloader) validate(w io.WriterAt, input *s3.GetObjectInput) error {
+	invalidParams := request.ErrInvalidParams{Context: "Downloader"}
+	if d.PartSize < 1 {
+		invalidParams.Add(request.NewErrParamMinValue("PartSize", 1))
+	}
+	if d.Concurrency < 1 {
+		invalidParams.Add(request.NewErrParamMinValue("Concurrency", 1))
+	}
+	if w == nil {
+		invalidParams.Add(request.NewErrParamRequired("WriterAt"))
+	}
+	if input == nil {
+		invalidParams.Add(request.NewErrParamRequired("GetObjectInput"))
+		return invalidParams
+	}
+	if input.Range != nil {
+		invalidParams.Add(NewErrParamFormat("Range", "cannot be downloaded, use GetObjectExtension"))
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// download is a download in progress.
+type download struct {
+	*Downloader
+	ctx aws.Context
+	w   io.WriterAt
+	// input is the input of every range, pinned to the ETag of the first.
+	input s3.GetObjectInput
+
+	mu   sync.Mutex
+	hash hash.Hash
+	// hashed is the number of bytes from the start of the object hashed.
+	hashed int64
+	// pending holds the ranges read but not hashed yet, by offset.
+	pending map[int64][]byte
+	// slots limits the ranges read ahead of the hash.
+	slots chan struct{}
+}
+
+func (d *download) run() (*GetObjectOutput, error) {
+	out, data, err := d.get(d.ctx, 0)
+	if failure, ok := err.(awserr.RequestFailure); ok && failure.StatusCode() == http.StatusRequestedRangeNotSatisfiable {
+		// The first range of an empty object cannot be satisfied.
+		out, data, err = d.get(d.ctx, -1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	size := int64(len(data))
+	if out.ContentRange != nil {
+		if size, err = rangeSize(*out.ContentRange); err != nil {
+			return nil, err
+		}
+	}
+	if out.ETag != nil {
+		d.input.IfMatch = out.ETag
+	}
+	if _, err := d.w.WriteAt(data, 0); err != nil {
+		return nil, err
+	}
+	d.consume(0, data)
+
+	if size > int64(len(data)) {
+		if err := d.readRanges(int64(len(data)), size); err != nil {
+			return nil, err
+		}
+	}
+
+	out.Body = nil
+	out.ContentLength = aws.Int64(size)
+	out.ContentRange = nil
+	if d.hashed != size {
+		return out, awserr.New(ErrCodeObjectSizeMismatch, fmt.Sprintf("read %d bytes of %s/%s, expected %d",
+			d.hashed, aws.StringValue(d.input.Bucket), aws.StringValue(d.input.Key), size), nil)
+	}
+	sum := hex.EncodeToString(d.hash.Sum(nil))
+	if out.ContentMD5EMC != nil && *out.ContentMD5EMC != sum {
+		return out, awserr.New(ErrCodeContentMD5Mismatch, fmt.Sprintf("x-emc-content-md5 of %s/%s is %s, read %s",
+			aws.StringValue(d.input.Bucket), aws.StringValue(d.input.Key), *out.ContentMD5EMC, sum), nil)
+	}
+	return out, nil
+}
+
+// readRanges reads the object from offset up to size, Concurrency ranges at
+// a time.
+func (d *download) readRanges(offset, size int64) error {
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		failure error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failure == nil {
+			failure = err
+			cancel()
+		}
+	}
+	d.slots = make(chan struct{}, 2*d.Concurrency)
+	offsets := make(chan int64)
+	for i := 0; i < d.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for offset := range offsets {
+				_, data, err := d.get(ctx, offset)
+				if err == nil {
+					err = d.checkRange(offset, size, data)
+				}
+				if err == nil {
+					_, err = d.w.WriteAt(data, offset)
+				}
+				if err != nil {
+					fail(err)
+					continue
+				}
+				d.consume(offset, data)
+			}
+		}()
+	}
+
+dispatch:
+	for ; offset < size; offset += d.PartSize {
+		// Wait until the hash catches up, so that a slow range does not
+		// make the ranges read after it pile up.
+		select {
+		case d.slots <- struct{}{}:
+		case <-ctx.Done():
+			break dispatch
+		}
+		select {
+		case offsets <- offset:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(offsets)
+	wg.Wait()
+
+	if failure == nil {
+		failure = ctx.Err()
+	}
+	if failure == context.Canceled || failure == context.DeadlineExceeded {
+		return awserr.New(request.CanceledErrorCode, "download context canceled", failure)
+	}
+	return failure
+}
+
+// get reads the range of PartSize at offset, or the whole object if offset
+// is negative, retrying failures.
+func (d *download) get(ctx aws.Context, offset int64) (*GetObjectOutput, []byte, error) {
+	input := d.input
+	if offset >= 0 {
+		input.Range = aws.String(UpdateRange(offset, d.PartSize))
+	}
+	for retries := 0; ; retries++ {
+		out, data, err := d.getOnce(ctx, &input)
+		if err == nil || !d.Retryer.retryAfter(ctx, err, retries) {
+			return out, data, err
+		}
+	}
+}
+
+// checkRange returns an error with the code ErrCodeObjectSizeMismatch if
+// data, read at offset of an object of size, is not the whole range there.
+// The hash would otherwise never reach the ranges after it.
+func (d *download) checkRange(offset, size int64, data []byte) error {
+	expected := d.PartSize
+	if size-offset < expected {
+		expected = size - offset
+	}
+	if int64(len(data)) != expected {
+		return awserr.New(ErrCodeObjectSizeMismatch, fmt.Sprintf("read %d bytes of %s/%s at %d, expected %d",
+			len(data), aws.StringValue(d.input.Bucket), aws.StringValue(d.input.Key), offset, expected), nil)
+	}
+	return nil
+}
+
+func (d *download) getOnce(ctx aws.Context, input *s3.GetObjectInput) (*GetObjectOutput, []byte, error) {
+	out, err := d.S3.GetObjectExtensionWithContext(ctx, input, d.RequestOptions...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer out.Body.Close()
+	data, err := ioutil.ReadAll(out.Body)
+	if err != nil {
+		// Reading the body fails like sending the request would.
+		return nil, nil, awserr.New("RequestError", "read object data failed", err)
+	}
+	return out, data, nil
+}
+
+// consume hashes data, read at offset, and the ranges pending after it once
+// all the data before it has been hashed.
+func (d *download) consume(offset int64, data []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.pending[offset] = data
+	for {
+		data, ok := d.pending[d.hashed]
+		if !ok {
+			return
+		}
+		delete(d.pending, d.hashed)
+		if d.slots != nil && d.hashed > 0 {
+			<-d.slots
+		}
+		d.hash.Write(data)
+		if len(data) == 0 {
+			return
+		}
+		d.hashed += int64(len(data))
+	}
+}
+
+// rangeSize returns the size of the object from the Content-Range of a
+// ranged read, such as "bytes 0-99/1000".
+func rangeSize(contentRange string) (int64, error) {
+	i := strings.LastIndex(contentRange, "/")
+	if i < 0 {
+		return 0, awserr.New(request.ErrCodeSerialization, fmt.Sprintf("invalid Content-Range %q", contentRange), nil)
+	}
+	size, err := strconv.ParseInt(contentRange[i+1:], 10, 64)
+	if err != nil {
+		return 0, awserr.New(request.ErrCodeSerialization, fmt.Sprintf("invalid Content-Range %q", contentRange), err)
+	}
+	return size, nil
+}
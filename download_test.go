@@ -0,0 +1,218 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func testDownloader(client ecs.DownloadAPI) *ecs.Downloader {
+	return ecs.NewDownloader(client, func(d *ecs.Downloader) {
+		d.PartSize = 1024
+		d.Concurrency = 4
+		d.Retryer = testRetryer()
+	})
+}
+
+// newDownloadServer returns a server holding b/k with data.
+func newDownloadServer(t *testing.T, data []byte) (*ecstest.Server, *requestLog) {
+	server, log := newUploadServer(t)
+	_, err := server.Client().PutObjectExtension(&ecs.PutObjectInput{
+		Bucket:          aws.String("b"),
+		Key:             aws.String("k"),
+		Body:            bytes.NewReader(data),
+		RetentionPeriod: aws.Int64(3600),
+		RetentionPolicy: aws.String("policy"),
+	})
+	assert.Nil(t, err)
+	return server, log
+}
+
+func TestDownload(t *testing.T) {
+	data, sum := uploadData(10*1024 + 5)
+	server, log := newDownloadServer(t, data)
+	defer server.Close()
+
+	w := &aws.WriteAtBuffer{}
+	out, err := testDownloader(server.Client()).Download(w, &s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		assert.True(t, bytes.Equal(data, w.Bytes()))
+		assert.Nil(t, out.Body)
+		assert.Equal(t, int64(len(data)), aws.Int64Value(out.ContentLength))
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+		assert.Equal(t, int64(3600), aws.Int64Value(out.RetentionPeriod))
+		assert.Equal(t, "policy", aws.StringValue(out.RetentionPolicy))
+	}
+
+	gets := log.filter(func(r *http.Request) bool { return r.Method == "GET" })
+	if assert.Len(t, gets, 11) {
+		for _, r := range gets[1:] {
+			assert.Equal(t, aws.StringValue(out.ETag), r.Header.Get("If-Match"))
+		}
+	}
+}
+
+func TestDownloadRetry(t *testing.T) {
+	data, sum := uploadData(4 * 1024)
+	server, _ := newDownloadServer(t, data)
+	defer server.Close()
+	server.AddFault(ecstest.Fault{
+		Match: func(r *http.Request) bool {
+			return r.Header.Get("Range") == "bytes=2048-3071"
+		},
+		Count:      2,
+		StatusCode: http.StatusServiceUnavailable,
+		Code:       "ServiceUnavailable",
+	})
+
+	w := &aws.WriteAtBuffer{}
+	out, err := testDownloader(server.Client()).Download(w, &s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		assert.True(t, bytes.Equal(data, w.Bytes()))
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+	}
+}
+
+// overwritingClient overwrites the object after the first read.
+type overwritingClient struct {
+	*ecs.S3
+	once sync.Once
+}
+
+func (c *overwritingClient) GetObjectExtensionWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*ecs.GetObjectOutput, error) {
+	out, err := c.S3.GetObjectExtensionWithContext(ctx, input, opts...)
+	c.once.Do(func() {
+		data, _ := uploadData(4 * 1024)
+		data[0]++
+		c.PutObject(&s3.PutObjectInput{Bucket: input.Bucket, Key: input.Key, Body: bytes.NewReader(data)})
+	})
+	return out, err
+}
+
+func TestDownloadChanged(t *testing.T) {
+	server, _ := newUploadServer(t)
+	defer server.Close()
+	data, _ := uploadData(4 * 1024)
+	_, err := server.Client().PutObject(&s3.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: bytes.NewReader(data)})
+	assert.Nil(t, err)
+
+	client := &overwritingClient{S3: server.Client()}
+	_, err = testDownloader(client).Download(&aws.WriteAtBuffer{}, &s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, http.StatusPreconditionFailed, err.(awserr.RequestFailure).StatusCode())
+	}
+}
+
+// truncatingClient drops the last byte of the ranges after the first.
+type truncatingClient struct {
+	*ecs.S3
+}
+
+func (c *truncatingClient) GetObjectExtensionWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*ecs.GetObjectOutput, error) {
+	out, err := c.S3.GetObjectExtensionWithContext(ctx, input, opts...)
+	if err != nil || strings.HasPrefix(aws.StringValue(input.Range), "bytes=0-") {
+		return out, err
+	}
+	data, _ := ioutil.ReadAll(out.Body)
+	out.Body.Close()
+	out.Body = ioutil.NopCloser(bytes.NewReader(data[:len(data)-1]))
+	return out, nil
+}
+
+func TestDownloadShortRange(t *testing.T) {
+	data, _ := uploadData(20 * 1024)
+	server, _ := newDownloadServer(t, data)
+	defer server.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := testDownloader(&truncatingClient{server.Client()}).Download(&aws.WriteAtBuffer{}, &s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ecs.ErrCodeObjectSizeMismatch, err.(awserr.Error).Code())
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("download of short ranges did not return")
+	}
+}
+
+func TestDownloadContentMD5Mismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("x-emc-content-md5", "0123456789abcdef0123456789abcdef")
+		w.Write([]byte("data"))
+	}))
+	defer server.Close()
+
+	w := &aws.WriteAtBuffer{}
+	out, err := testDownloader(newLocalClient(server.URL)).Download(w, &s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, ecs.ErrCodeContentMD5Mismatch, err.(awserr.Error).Code())
+	}
+	if assert.NotNil(t, out) {
+		assert.Equal(t, int64(4), aws.Int64Value(out.ContentLength))
+	}
+	assert.Equal(t, "data", string(w.Bytes()))
+}
+
+func TestDownloadFake(t *testing.T) {
+	fake := ecstest.NewFake()
+	_, err := fake.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	downloader := testDownloader(fake)
+
+	for _, size := range []int64{0, 1000, 1024, 5000} {
+		data, sum := uploadData(size)
+		_, err := fake.PutObjectExtension(&ecs.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: bytes.NewReader(data)})
+		assert.Nil(t, err)
+
+		w := &aws.WriteAtBuffer{}
+		out, err := downloader.Download(w, &s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+		if assert.Nil(t, err, size) {
+			assert.True(t, bytes.Equal(data, w.Bytes()), size)
+			assert.Equal(t, size, aws.Int64Value(out.ContentLength))
+			assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+		}
+	}
+}
+
+func TestDownloadValidate(t *testing.T) {
+	_, err := ecs.NewDownloader(ecstest.NewFake()).Download(&aws.WriteAtBuffer{}, &s3.GetObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Range:  aws.String("bytes=0-1"),
+	})
+	if assert.NotNil(t, err) {
+		assert.True(t, strings.Contains(err.Error(), "Range"))
+	}
+}
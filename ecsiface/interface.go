@@ -0,0 +1,152 @@
+// Package ecsiface provides an interface to enable mocking the ECS client
+// for testing your code.
+//
+// It is important to note that this interface will have breaking changes
+// when new ECS extension operations are added.
+package ecsiface
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"io"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// ECSAPI provides an interface to enable mocking the ecs.S3 client's
+// extension operations, paginators and helpers together with the plain S3
+// operations of s3iface.S3API.
+//
+//	// myFunc uses an ECS client to make a request.
+//	func myFunc(svc ecsiface.ECSAPI) bool {
+//	    // Make svc.ListBucketQuery request
+//	}
+//
+//	func main() {
+//	    sess := session.New()
+//	    svc := ecs.NewWithOptions(sess)
+//
+//	    myFunc(svc)
+//	}
+//
+// In your _test.go file, either use the in-memory implementation of the
+// ecstest package:
+//
+//	func TestMyFunc(t *testing.T) {
+//	    myFunc(ecstest.NewFake())
+//	}
+//
+// or define a mock struct:
+//
+//	type mockECSClient struct {
+//	    ecsiface.ECSAPI
+//	}
+//	func (m *mockECSClient) ListBucketQuery(input *ecs.ListBucketQueryInput) (*ecs.ListBucketQueryOutput, error) {
+//	    // mock response/functionality
+//	}
+type ECSAPI interface {
+	s3iface.S3API
+
+	CreateBucketExtension(*ecs.CreateBucketInput) (*s3.CreateBucketOutput, error)
+	CreateBucketExtensionWithContext(aws.Context, *ecs.CreateBucketInput, ...request.Option) (*s3.CreateBucketOutput, error)
+	CreateBucketExtensionRequest(*ecs.CreateBucketInput) (*request.Request, *s3.CreateBucketOutput)
+
+	DeleteBucketMetadataSearch(*ecs.DeleteBucketMetadataSearchInput) (*ecs.DeleteBucketMetadataSearchOutput, error)
+	DeleteBucketMetadataSearchWithContext(aws.Context, *ecs.DeleteBucketMetadataSearchInput, ...request.Option) (*ecs.DeleteBucketMetadataSearchOutput, error)
+	DeleteBucketMetadataSearchRequest(*ecs.DeleteBucketMetadataSearchInput) (*request.Request, *ecs.DeleteBucketMetadataSearchOutput)
+
+	GetBucketIsStaleAllowed(*ecs.GetBucketIsStaleAllowedInput) (*ecs.GetBucketIsStaleAllowedOutput, error)
+	GetBucketIsStaleAllowedWithContext(aws.Context, *ecs.GetBucketIsStaleAllowedInput, ...request.Option) (*ecs.GetBucketIsStaleAllowedOutput, error)
+	GetBucketIsStaleAllowedRequest(*ecs.GetBucketIsStaleAllowedInput) (*request.Request, *ecs.GetBucketIsStaleAllowedOutput)
+
+	GetBucketRetention(*ecs.GetBucketRetentionInput) (*ecs.GetBucketRetentionOutput, error)
+	GetBucketRetentionWithContext(aws.Context, *ecs.GetBucketRetentionInput, ...request.Option) (*ecs.GetBucketRetentionOutput, error)
+	GetBucketRetentionRequest(*ecs.GetBucketRetentionInput) (*request.Request, *ecs.GetBucketRetentionOutput)
+
+	GetObjectExtension(*s3.GetObjectInput) (*ecs.GetObjectOutput, error)
+	GetObjectExtensionWithContext(aws.Context, *s3.GetObjectInput, ...request.Option) (*ecs.GetObjectOutput, error)
+	GetObjectExtensionRequest(*s3.GetObjectInput) (*request.Request, *ecs.GetObjectOutput)
+
+	GetObjectRetention(*ecs.GetObjectRetentionInput) (*ecs.GetObjectRetentionOutput, error)
+	GetObjectRetentionWithContext(aws.Context, *ecs.GetObjectRetentionInput, ...request.Option) (*ecs.GetObjectRetentionOutput, error)
+	GetObjectRetentionRequest(*ecs.GetObjectRetentionInput) (*request.Request, *ecs.GetObjectRetentionOutput)
+
+	GetSystemMetadataSearchKeys(*ecs.GetSystemMetadataSearchKeysInput) (*ecs.GetSystemMetadataSearchKeysOutput, error)
+	GetSystemMetadataSearchKeysWithContext(aws.Context, *ecs.GetSystemMetadataSearchKeysInput, ...request.Option) (*ecs.GetSystemMetadataSearchKeysOutput, error)
+	GetSystemMetadataSearchKeysRequest(*ecs.GetSystemMetadataSearchKeysInput) (*request.Request, *ecs.GetSystemMetadataSearchKeysOutput)
+
+	HeadBucketExtension(*s3.HeadBucketInput) (*ecs.HeadBucketOutput, error)
+	HeadBucketExtensionWithContext(aws.Context, *s3.HeadBucketInput, ...request.Option) (*ecs.HeadBucketOutput, error)
+	HeadBucketExtensionRequest(*s3.HeadBucketInput) (*request.Request, *ecs.HeadBucketOutput)
+
+	HeadObjectExtension(*s3.HeadObjectInput) (*ecs.HeadObjectOutput, error)
+	HeadObjectExtensionWithContext(aws.Context, *s3.HeadObjectInput, ...request.Option) (*ecs.HeadObjectOutput, error)
+	HeadObjectExtensionRequest(*s3.HeadObjectInput) (*request.Request, *ecs.HeadObjectOutput)
+
+	ListBucketMetadataSearch(*ecs.ListBucketMetadataSearchInput) (*ecs.ListBucketMetadataSearchOutput, error)
+	ListBucketMetadataSearchWithContext(aws.Context, *ecs.ListBucketMetadataSearchInput, ...request.Option) (*ecs.ListBucketMetadataSearchOutput, error)
+	ListBucketMetadataSearchRequest(*ecs.ListBucketMetadataSearchInput) (*request.Request, *ecs.ListBucketMetadataSearchOutput)
+
+	ListBucketQuery(*ecs.ListBucketQueryInput) (*ecs.ListBucketQueryOutput, error)
+	ListBucketQueryWithContext(aws.Context, *ecs.ListBucketQueryInput, ...request.Option) (*ecs.ListBucketQueryOutput, error)
+	ListBucketQueryRequest(*ecs.ListBucketQueryInput) (*request.Request, *ecs.ListBucketQueryOutput)
+
+	ListBucketQueryPages(*ecs.ListBucketQueryInput, func(*ecs.ListBucketQueryOutput, bool) bool) error
+	ListBucketQueryPagesWithContext(aws.Context, *ecs.ListBucketQueryInput, func(*ecs.ListBucketQueryOutput, bool) bool, ...request.Option) error
+
+	NewObjectMatchIterator(aws.Context, *ecs.ListBucketQueryInput, ...request.Option) *ecs.ObjectMatchIterator
+	ListBucketQueryMatches(aws.Context, *ecs.ListBucketQueryInput, ...request.Option) (<-chan *ecs.EcsObjectMatch, <-chan error)
+
+	PutBucketIsStaleAllowed(*ecs.PutBucketIsStaleAllowedInput) (*ecs.PutBucketIsStaleAllowedOutput, error)
+	PutBucketIsStaleAllowedWithContext(aws.Context, *ecs.PutBucketIsStaleAllowedInput, ...request.Option) (*ecs.PutBucketIsStaleAllowedOutput, error)
+	PutBucketIsStaleAllowedRequest(*ecs.PutBucketIsStaleAllowedInput) (*request.Request, *ecs.PutBucketIsStaleAllowedOutput)
+
+	PutBucketRetention(*ecs.PutBucketRetentionInput) (*ecs.PutBucketRetentionOutput, error)
+	PutBucketRetentionWithContext(aws.Context, *ecs.PutBucketRetentionInput, ...request.Option) (*ecs.PutBucketRetentionOutput, error)
+	PutBucketRetentionRequest(*ecs.PutBucketRetentionInput) (*request.Request, *ecs.PutBucketRetentionOutput)
+
+	PutObjectExtension(*ecs.PutObjectInput) (*ecs.PutObjectOutput, error)
+	PutObjectExtensionWithContext(aws.Context, *ecs.PutObjectInput, ...request.Option) (*ecs.PutObjectOutput, error)
+	PutObjectExtensionRequest(*ecs.PutObjectInput) (*request.Request, *ecs.PutObjectOutput)
+
+	PutObjectRetention(*ecs.PutObjectRetentionInput) (*ecs.PutObjectRetentionOutput, error)
+	PutObjectRetentionWithContext(aws.Context, *ecs.PutObjectRetentionInput, ...request.Option) (*ecs.PutObjectRetentionOutput, error)
+	PutObjectRetentionRequest(*ecs.PutObjectRetentionInput) (*request.Request, *ecs.PutObjectRetentionOutput)
+
+	GetBucketInfo(string) (*ecs.BucketInfo, error)
+	GetBucketInfoWithContext(aws.Context, string, ...request.Option) (*ecs.BucketInfo, error)
+
+	CheckBucketRetentionReduction(string, int64) error
+	CheckBucketRetentionReductionWithContext(aws.Context, string, int64, ...request.Option) error
+
+	GetObjectRanges(*s3.GetObjectInput, []ecs.ByteRange) (*ecs.GetObjectRangesOutput, error)
+	GetObjectRangesWithContext(aws.Context, *s3.GetObjectInput, []ecs.ByteRange, ...request.Option) (*ecs.GetObjectRangesOutput, error)
+
+	UpdateObjectRange(string, string, int64, io.ReadSeeker) (*ecs.PutObjectOutput, error)
+	UpdateObjectRangeWithContext(aws.Context, string, string, int64, io.ReadSeeker, ...request.Option) (*ecs.PutObjectOutput, error)
+
+	OverwriteObjectRange(string, string, int64, io.ReadSeeker) (*ecs.PutObjectOutput, error)
+	OverwriteObjectRangeWithContext(aws.Context, string, string, int64, io.ReadSeeker, ...request.Option) (*ecs.PutObjectOutput, error)
+
+	AppendObject(string, string, io.ReadSeeker) (*ecs.PutObjectOutput, error)
+	AppendObjectWithContext(aws.Context, string, string, io.ReadSeeker, ...request.Option) (*ecs.PutObjectOutput, error)
+}
+
+var _ ECSAPI = (*ecs.S3)(nil)
@@ -0,0 +1,349 @@
+package ecstest
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// store holds the buckets of a Server or Fake.
+type store struct {
+	buckets map[string]*bucket
+}
+
+func (s *store) bucket(name string) (*bucket, error) {
+	b := s.buckets[name]
+	if b == nil {
+		return nil, errNoSuchBucket
+	}
+	return b, nil
+}
+
+func (s *store) addBucket(b *bucket) error {
+	if _, ok := s.buckets[b.name]; ok {
+		return errBucketAlreadyExists
+	}
+	s.buckets[b.name] = b
+	return nil
+}
+
+func (s *store) deleteBucket(name string) error {
+	b, err := s.bucket(name)
+	if err != nil {
+		return err
+	}
+	if len(b.objects) > 0 {
+		return errBucketNotEmpty
+	}
+	delete(s.buckets, name)
+	return nil
+}
+
+type bucket struct {
+	name    string
+	created time.Time
+
+	complianceEnabled bool
+	fileSystemAccess  bool
+	isStaleAllowed    bool
+	listDuringOutage  bool
+	sseEnabled        bool
+	tsoReadOnly       bool
+	namespace         string
+	vpool             string
+	retentionPeriod   int64
+	// metadataSearch is nil when metadata search is disabled.
+	metadataSearch *ecs.MetadataSearchIndex
+
+	objects map[string]*object
+	// uploads are the multipart uploads in progress, by upload ID.
+	uploads      map[string]*multipartUpload
+	lastUploadID int
+}
+
+func newBucket(name string, now time.Time) *bucket {
+	return &bucket{name: name, created: now, objects: map[string]*object{}, uploads: map[string]*multipartUpload{}}
+}
+
+// setMetadataSearch enables metadata search with the index described by v,
+// the value of the x-emc-metadata-search header.
+func (b *bucket) setMetadataSearch(v string) error {
+	index, err := ecs.ParseMetadataSearchIndex(v)
+	if err == nil {
+		err = index.Validate()
+	}
+	if err != nil {
+		return invalidArgument(err.Error())
+	}
+	b.metadataSearch = index
+	return nil
+}
+
+func (b *bucket) setRetentionPeriod(period int64) error {
+	if period < 0 {
+		return errInvalidRetentionPeriod
+	}
+	if b.complianceEnabled && period < b.retentionPeriod {
+		return retentionReduction("The retention period of a compliance-enabled bucket cannot be reduced")
+	}
+	b.retentionPeriod = period
+	return nil
+}
+
+// indexableKeys returns the keys indexed for metadata search.
+func (b *bucket) indexableKeys() []indexableKey {
+	var keys []indexableKey
+	if b.metadataSearch != nil {
+		for _, name := range b.metadataSearch.SystemKeys {
+			datatype, _ := ecs.SystemMetadataSearchKeyDatatype(name)
+			keys = append(keys, indexableKey{name, datatype})
+		}
+		for _, k := range b.metadataSearch.UserKeys {
+			keys = append(keys, indexableKey{k.Name, k.Datatype})
+		}
+	}
+	return keys
+}
+
+type bucketEntry struct {
+	Name         string
+	CreationDate time.Time
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name      `xml:"ListAllMyBucketsResult"`
+	Buckets []bucketEntry `xml:"Buckets>Bucket"`
+}
+
+type indexableKey struct {
+	Name     string
+	Datatype string
+}
+
+type metadataSearchList struct {
+	XMLName               xml.Name       `xml:"MetadataSearchList"`
+	MetadataSearchEnabled *bool          `xml:",omitempty"`
+	IndexableKeys         []indexableKey `xml:"IndexableKeys>Key"`
+	OptionalAttributes    []indexableKey `xml:"OptionalAttributes>Attribute"`
+}
+
+// optionalAttributes are the attributes a query can request.
+var optionalAttributes = []indexableKey{
+	{"ContentType", ecs.DatatypeString},
+	{"Expiration", ecs.DatatypeDatetime},
+	{"Expires", ecs.DatatypeDatetime},
+	{"Retention", ecs.DatatypeInteger},
+}
+
+func systemIndexableKeys() []indexableKey {
+	var names []string
+	for name := range ecs.SystemMetadataSearchKeys {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var keys []indexableKey
+	for _, name := range names {
+		keys = append(keys, indexableKey{name, ecs.SystemMetadataSearchKeys[name]})
+	}
+	return keys
+}
+
+func bucketNames(buckets map[string]*bucket) []string {
+	names := make([]string, 0, len(buckets))
+	for name := range buckets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) serveBucket(w http.ResponseWriter, r *http.Request, name string) {
+	query := r.URL.Query()
+	if r.Method == "PUT" && len(query) == 0 {
+		if err := s.createBucket(r, name); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		w.Header().Set("Location", "/"+name)
+		return
+	}
+	b, err := s.bucket(name)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	_, searchMetadata := query["searchmetadata"]
+	_, queryObjects := query["query"]
+	_, isStaleAllowed := query["isstaleallowed"]
+	_, retentionPeriod := query["retentionperiod"]
+	switch {
+	case r.Method == "HEAD":
+		b.writeHeaders(w)
+	case r.Method == "DELETE" && searchMetadata:
+		b.metadataSearch = nil
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "DELETE":
+		if err := s.deleteBucket(name); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "GET" && searchMetadata:
+		writeXML(w, &metadataSearchList{
+			MetadataSearchEnabled: aws.Bool(b.metadataSearch != nil),
+			IndexableKeys:         b.indexableKeys(),
+			OptionalAttributes:    optionalAttributes,
+		})
+	case r.Method == "GET" && queryObjects:
+		serveQuery(w, r, b)
+	case r.Method == "GET":
+		b.serveListObjects(w, r)
+	case r.Method == "PUT" && isStaleAllowed:
+		b.isStaleAllowed = headerBool(r, "x-emc-is-stale-allowed")
+		if r.Header.Get("x-emc-list-during-outage") != "" {
+			b.listDuringOutage = headerBool(r, "x-emc-list-during-outage")
+		}
+		if r.Header.Get("x-emc-tso-read-only") != "" {
+			b.tsoReadOnly = headerBool(r, "x-emc-tso-read-only")
+		}
+	case r.Method == "PUT" && retentionPeriod:
+		period, err := strconv.ParseInt(r.Header.Get("x-emc-retention-period"), 10, 64)
+		if err != nil {
+			writeAPIError(w, errInvalidRetentionPeriod)
+			return
+		}
+		writeAPIError(w, b.setRetentionPeriod(period))
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "ecstest does not implement this bucket operation")
+	}
+}
+
+func (s *Server) createBucket(r *http.Request, name string) error {
+	b := newBucket(name, s.Now())
+	b.complianceEnabled = headerBool(r, "x-emc-compliance-enabled")
+	b.fileSystemAccess = headerBool(r, "x-emc-file-system-access-enabled")
+	b.isStaleAllowed = headerBool(r, "x-emc-is-stale-allowed")
+	b.listDuringOutage = headerBool(r, "x-emc-list-during-outage")
+	b.sseEnabled = headerBool(r, "x-emc-server-side-encryption-enabled")
+	b.tsoReadOnly = headerBool(r, "x-emc-tso-read-only")
+	b.namespace = r.Header.Get("x-emc-namespace")
+	b.vpool = r.Header.Get("x-emc-vpool")
+	if v := r.Header.Get("x-emc-retention-period"); v != "" {
+		period, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || period < 0 {
+			return errInvalidRetentionPeriod
+		}
+		b.retentionPeriod = period
+	}
+	if v := r.Header.Get("x-emc-metadata-search"); v != "" {
+		if err := b.setMetadataSearch(v); err != nil {
+			return err
+		}
+	}
+	return s.addBucket(b)
+}
+
+func headerBool(r *http.Request, name string) bool {
+	v, _ := strconv.ParseBool(r.Header.Get(name))
+	return v
+}
+
+func (b *bucket) writeHeaders(w http.ResponseWriter) {
+	h := w.Header()
+	h.Set("x-emc-compliance-enabled", strconv.FormatBool(b.complianceEnabled))
+	h.Set("x-emc-file-system-access-enabled", strconv.FormatBool(b.fileSystemAccess))
+	h.Set("x-emc-is-stale-allowed", strconv.FormatBool(b.isStaleAllowed))
+	h.Set("x-emc-list-during-outage", strconv.FormatBool(b.listDuringOutage))
+	h.Set("x-emc-server-side-encryption-enabled", strconv.FormatBool(b.sseEnabled))
+	h.Set("x-emc-tso-read-only", strconv.FormatBool(b.tsoReadOnly))
+	h.Set("x-emc-retention-period", strconv.FormatInt(b.retentionPeriod, 10))
+	if b.namespace != "" {
+		h.Set("x-emc-namespace", b.namespace)
+	}
+	if b.vpool != "" {
+		h.Set("x-emc-vpool", b.vpool)
+	}
+	if b.metadataSearch != nil {
+		h.Set("x-emc-metadata-search", b.metadataSearch.String())
+	}
+}
+
+type listBucketResult struct {
+	XMLName     xml.Name `xml:"ListBucketResult"`
+	Name        string
+	Prefix      string
+	Marker      string
+	NextMarker  string `xml:",omitempty"`
+	MaxKeys     int
+	IsTruncated bool
+	Contents    []objectEntry
+}
+
+type objectEntry struct {
+	Key          string
+	LastModified time.Time
+	ETag         string
+	Size         int64
+	StorageClass string
+}
+
+func (b *bucket) serveListObjects(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	maxKeys := 1000
+	if v, err := strconv.Atoi(query.Get("max-keys")); err == nil && v >= 0 {
+		maxKeys = v
+	}
+	writeXML(w, b.listObjects(query.Get("prefix"), query.Get("marker"), maxKeys))
+}
+
+// listObjects returns the page of up to maxKeys objects of b after marker
+// whose keys start with prefix.
+func (b *bucket) listObjects(prefix, marker string, maxKeys int) *listBucketResult {
+	out := &listBucketResult{
+		Name:    b.name,
+		Prefix:  prefix,
+		Marker:  marker,
+		MaxKeys: maxKeys,
+	}
+	for _, key := range objectKeys(b.objects) {
+		if !strings.HasPrefix(key, out.Prefix) || key <= out.Marker {
+			continue
+		}
+		if len(out.Contents) == out.MaxKeys {
+			out.IsTruncated = true
+			out.NextMarker = out.Contents[len(out.Contents)-1].Key
+			break
+		}
+		o := b.objects[key]
+		out.Contents = append(out.Contents, objectEntry{
+			Key:          key,
+			LastModified: o.modified.UTC(),
+			ETag:         o.etag(),
+			Size:         int64(len(o.data)),
+			StorageClass: "STANDARD",
+		})
+	}
+	return out
+}
@@ -0,0 +1,871 @@
+package ecstest
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecsiface"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Fake is an in-memory implementation of ecsiface.ECSAPI. It models buckets,
+// objects, metadata search indexes and queries, retention and byte range
+// updates like Server does, without HTTP.
+//
+// Fake implements the ECS extension operations and helpers, and the plain S3
+// operations on buckets, objects and multipart uploads that ecs.Uploader,
+// ecs.Downloader and ecs.ParallelRangeUploader use: CreateBucket,
+// DeleteBucket, HeadBucket, ListObjects, PutObject, GetObject, HeadObject,
+// CopyObject, DeleteObject, CreateMultipartUpload, UploadPart,
+// CompleteMultipartUpload and AbortMultipartUpload. The XxxRequest methods
+// and the other S3 operations are forwarded to the embedded ECSAPI, which
+// fails them with the code NotImplemented unless replaced by the test.
+// Request options are ignored, but for the headers they set on
+// CreateMultipartUpload.
+//
+// Errors are awserr.RequestFailure values carrying the codes ECS returns, and
+// requests refused because of object retention fail with an
+// ecs.RetentionError, as with ecs.S3.
+type Fake struct {
+	ecsiface.ECSAPI
+
+	// Now returns the current time of the fake. Tests may replace it to
+	// control object retention; it defaults to time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex
+	store
+}
+
+var _ ecsiface.ECSAPI = (*Fake)(nil)
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{ECSAPI: notImplemented(), Now: time.Now, store: store{buckets: map[string]*bucket{}}}
+}
+
+// do runs fn under the lock of f unless ctx is done, and converts the error it
+// returns the way ecs.S3 would report it.
+func (f *Fake) do(ctx aws.Context, fn func(now time.Time) error) error {
+	if err := ctx.Err(); err != nil {
+		return awserr.New(request.CanceledErrorCode, "request context canceled", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return requestFailure(fn(f.Now()))
+}
+
+func requestFailure(err error) error {
+	e, ok := err.(*apiError)
+	if !ok {
+		return err
+	}
+	failure := awserr.NewRequestFailure(awserr.New(e.code, e.message, nil), e.status, "ecstest")
+	if e.code == ecs.ErrCodeObjectUnderRetention {
+		return &ecs.RetentionError{RequestFailure: failure}
+	}
+	return failure
+}
+
+// CreateBucketExtension creates a bucket with the ECS properties of input.
+func (f *Fake) CreateBucketExtension(input *ecs.CreateBucketInput) (*s3.CreateBucketOutput, error) {
+	return f.CreateBucketExtensionWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateBucketExtensionWithContext is the same as CreateBucketExtension with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) CreateBucketExtensionWithContext(ctx aws.Context, input *ecs.CreateBucketInput, opts ...request.Option) (*s3.CreateBucketOutput, error) {
+	name := aws.StringValue(input.Bucket)
+	err := f.do(ctx, func(now time.Time) error {
+		b := newBucket(name, now)
+		b.complianceEnabled = aws.BoolValue(input.ComplianceEnabled)
+		b.fileSystemAccess = aws.BoolValue(input.FileSystemAccess)
+		b.isStaleAllowed = aws.BoolValue(input.IsStaleAllowed)
+		b.listDuringOutage = aws.BoolValue(input.ListDuringOutage)
+		b.sseEnabled = aws.BoolValue(input.SSEEnabled)
+		b.tsoReadOnly = aws.BoolValue(input.TSOReadOnly)
+		b.namespace = aws.StringValue(input.NameSpace)
+		b.vpool = aws.StringValue(input.VPool)
+		if input.RetentionPeriod != nil {
+			if err := b.setRetentionPeriod(*input.RetentionPeriod); err != nil {
+				return err
+			}
+		}
+		if input.MetadataSearch != nil {
+			if err := b.setMetadataSearch(*input.MetadataSearch); err != nil {
+				return err
+			}
+		}
+		return f.addBucket(b)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.CreateBucketOutput{Location: aws.String("/" + name)}, nil
+}
+
+// DeleteBucket deletes an empty bucket.
+func (f *Fake) DeleteBucket(input *s3.DeleteBucketInput) (*s3.DeleteBucketOutput, error) {
+	return f.DeleteBucketWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteBucketWithContext is the same as DeleteBucket with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) DeleteBucketWithContext(ctx aws.Context, input *s3.DeleteBucketInput, opts ...request.Option) (*s3.DeleteBucketOutput, error) {
+	err := f.do(ctx, func(now time.Time) error {
+		return f.deleteBucket(aws.StringValue(input.Bucket))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteBucketOutput{}, nil
+}
+
+// DeleteBucketMetadataSearch disables metadata search on a bucket.
+func (f *Fake) DeleteBucketMetadataSearch(input *ecs.DeleteBucketMetadataSearchInput) (*ecs.DeleteBucketMetadataSearchOutput, error) {
+	return f.DeleteBucketMetadataSearchWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteBucketMetadataSearchWithContext is the same as DeleteBucketMetadataSearch with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) DeleteBucketMetadataSearchWithContext(ctx aws.Context, input *ecs.DeleteBucketMetadataSearchInput, opts ...request.Option) (*ecs.DeleteBucketMetadataSearchOutput, error) {
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		b.metadataSearch = nil
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.DeleteBucketMetadataSearchOutput{}, nil
+}
+
+// DeleteObject deletes an object. Deleting a missing object succeeds.
+func (f *Fake) DeleteObject(input *s3.DeleteObjectInput) (*s3.DeleteObjectOutput, error) {
+	return f.DeleteObjectWithContext(aws.BackgroundContext(), input)
+}
+
+// DeleteObjectWithContext is the same as DeleteObject with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) DeleteObjectWithContext(ctx aws.Context, input *s3.DeleteObjectInput, opts ...request.Option) (*s3.DeleteObjectOutput, error) {
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		return b.deleteObject(aws.StringValue(input.Key), now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.DeleteObjectOutput{}, nil
+}
+
+// GetBucketInfo returns every ECS property of bucket.
+func (f *Fake) GetBucketInfo(bucket string) (*ecs.BucketInfo, error) {
+	return f.GetBucketInfoWithContext(aws.BackgroundContext(), bucket)
+}
+
+// GetBucketInfoWithContext is the same as GetBucketInfo with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetBucketInfoWithContext(ctx aws.Context, bucket string, opts ...request.Option) (*ecs.BucketInfo, error) {
+	var info *ecs.BucketInfo
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(bucket)
+		if err != nil {
+			return err
+		}
+		info = &ecs.BucketInfo{
+			Bucket:            aws.String(b.name),
+			ComplianceEnabled: aws.Bool(b.complianceEnabled),
+			FileSystemAccess:  aws.Bool(b.fileSystemAccess),
+			IsStaleAllowed:    aws.Bool(b.isStaleAllowed),
+			ListDuringOutage:  aws.Bool(b.listDuringOutage),
+			RetentionPeriod:   aws.Int64(b.retentionPeriod),
+			SSEEnabled:        aws.Bool(b.sseEnabled),
+			TSOReadOnly:       aws.Bool(b.tsoReadOnly),
+		}
+		if b.namespace != "" {
+			info.NameSpace = aws.String(b.namespace)
+		}
+		if b.vpool != "" {
+			info.VPool = aws.String(b.vpool)
+		}
+		if b.metadataSearch != nil {
+			info.MetadataSearchIndex, err = ecs.ParseMetadataSearchIndex(b.metadataSearch.String())
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// GetBucketIsStaleAllowed returns the access during outage settings of a
+// bucket.
+func (f *Fake) GetBucketIsStaleAllowed(input *ecs.GetBucketIsStaleAllowedInput) (*ecs.GetBucketIsStaleAllowedOutput, error) {
+	return f.GetBucketIsStaleAllowedWithContext(aws.BackgroundContext(), input)
+}
+
+// GetBucketIsStaleAllowedWithContext is the same as GetBucketIsStaleAllowed with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetBucketIsStaleAllowedWithContext(ctx aws.Context, input *ecs.GetBucketIsStaleAllowedInput, opts ...request.Option) (*ecs.GetBucketIsStaleAllowedOutput, error) {
+	out := &ecs.GetBucketIsStaleAllowedOutput{}
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		out.IsStaleAllowed = aws.Bool(b.isStaleAllowed)
+		out.ListDuringOutage = aws.Bool(b.listDuringOutage)
+		out.TSOReadOnly = aws.Bool(b.tsoReadOnly)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetBucketRetention returns the retention period of a bucket.
+func (f *Fake) GetBucketRetention(input *ecs.GetBucketRetentionInput) (*ecs.GetBucketRetentionOutput, error) {
+	return f.GetBucketRetentionWithContext(aws.BackgroundContext(), input)
+}
+
+// GetBucketRetentionWithContext is the same as GetBucketRetention with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetBucketRetentionWithContext(ctx aws.Context, input *ecs.GetBucketRetentionInput, opts ...request.Option) (*ecs.GetBucketRetentionOutput, error) {
+	out := &ecs.GetBucketRetentionOutput{}
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		out.ComplianceEnabled = aws.Bool(b.complianceEnabled)
+		out.RetentionPeriod = aws.Int64(b.retentionPeriod)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CheckBucketRetentionReduction fails if period would reduce the retention
+// period of a compliance-enabled bucket.
+func (f *Fake) CheckBucketRetentionReduction(bucket string, period int64) error {
+	return f.CheckBucketRetentionReductionWithContext(aws.BackgroundContext(), bucket, period)
+}
+
+// CheckBucketRetentionReductionWithContext is the same as CheckBucketRetentionReduction with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) CheckBucketRetentionReductionWithContext(ctx aws.Context, bucket string, period int64, opts ...request.Option) error {
+	current, err := f.GetBucketRetentionWithContext(ctx, &ecs.GetBucketRetentionInput{Bucket: aws.String(bucket)}, opts...)
+	if err != nil {
+		return err
+	}
+	return ecs.CheckRetentionReduction(bucket, period, current)
+}
+
+// GetObjectExtension reads an object. Range and conditional requests are
+// answered like net/http serves content.
+func (f *Fake) GetObjectExtension(input *s3.GetObjectInput) (*ecs.GetObjectOutput, error) {
+	return f.GetObjectExtensionWithContext(aws.BackgroundContext(), input)
+}
+
+// GetObjectExtensionWithContext is the same as GetObjectExtension with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetObjectExtensionWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*ecs.GetObjectOutput, error) {
+	rec, err := f.readObject(ctx, "GET", input)
+	if err != nil {
+		return nil, err
+	}
+	h := rec.Header()
+	out := &ecs.GetObjectOutput{
+		AcceptRanges:    headerString(h, "Accept-Ranges"),
+		Body:            ioutil.NopCloser(rec.Body),
+		ContentLength:   aws.Int64(int64(rec.Body.Len())),
+		ContentMD5EMC:   headerString(h, "x-emc-content-md5"),
+		ContentRange:    headerString(h, "Content-Range"),
+		ContentType:     headerString(h, "Content-Type"),
+		ETag:            headerString(h, "ETag"),
+		LastModified:    headerTime(h, "Last-Modified"),
+		Metadata:        headerMetadata(h),
+		RetentionPeriod: headerInt64(h, "x-emc-retention-period"),
+		RetentionPolicy: headerString(h, "x-emc-retention-policy"),
+	}
+	return out, nil
+}
+
+// GetObjectRanges reads several byte ranges of an object.
+func (f *Fake) GetObjectRanges(input *s3.GetObjectInput, ranges []ecs.ByteRange) (*ecs.GetObjectRangesOutput, error) {
+	return f.GetObjectRangesWithContext(aws.BackgroundContext(), input, ranges)
+}
+
+// GetObjectRangesWithContext is the same as GetObjectRanges with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetObjectRangesWithContext(ctx aws.Context, input *s3.GetObjectInput, ranges []ecs.ByteRange, opts ...request.Option) (*ecs.GetObjectRangesOutput, error) {
+	if err := ecs.ValidateByteRanges(ranges); err != nil {
+		return nil, err
+	}
+	specs := make([]string, len(ranges))
+	for i, r := range ranges {
+		specs[i] = r.String()
+	}
+	var in s3.GetObjectInput
+	if input != nil {
+		in = *input
+	}
+	in.Range = aws.String("bytes=" + strings.Join(specs, ","))
+	out, err := f.GetObjectExtensionWithContext(ctx, &in)
+	if err != nil {
+		return nil, err
+	}
+	return ecs.NewGetObjectRangesOutput(out)
+}
+
+// GetObjectRetention returns the retention of an object.
+func (f *Fake) GetObjectRetention(input *ecs.GetObjectRetentionInput) (*ecs.GetObjectRetentionOutput, error) {
+	return f.GetObjectRetentionWithContext(aws.BackgroundContext(), input)
+}
+
+// GetObjectRetentionWithContext is the same as GetObjectRetention with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetObjectRetentionWithContext(ctx aws.Context, input *ecs.GetObjectRetentionInput, opts ...request.Option) (*ecs.GetObjectRetentionOutput, error) {
+	out := &ecs.GetObjectRetentionOutput{}
+	err := f.do(ctx, func(now time.Time) error {
+		o, err := f.object(aws.StringValue(input.Bucket), aws.StringValue(input.Key))
+		if err != nil {
+			return err
+		}
+		out.LastModified = aws.Time(o.modified.UTC().Truncate(time.Second))
+		out.RetentionPeriod = o.retentionPeriod
+		if o.retentionPolicy != "" {
+			out.RetentionPolicy = aws.String(o.retentionPolicy)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetSystemMetadataSearchKeys returns the system metadata keys that can be
+// indexed and the optional attributes queries can request.
+func (f *Fake) GetSystemMetadataSearchKeys(input *ecs.GetSystemMetadataSearchKeysInput) (*ecs.GetSystemMetadataSearchKeysOutput, error) {
+	return f.GetSystemMetadataSearchKeysWithContext(aws.BackgroundContext(), input)
+}
+
+// GetSystemMetadataSearchKeysWithContext is the same as GetSystemMetadataSearchKeys with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetSystemMetadataSearchKeysWithContext(ctx aws.Context, input *ecs.GetSystemMetadataSearchKeysInput, opts ...request.Option) (*ecs.GetSystemMetadataSearchKeysOutput, error) {
+	out := &ecs.GetSystemMetadataSearchKeysOutput{
+		IndexableKeys:      ecsIndexableKeys(systemIndexableKeys()),
+		OptionalAttributes: ecsOptionalAttributes(),
+	}
+	if err := f.do(ctx, func(now time.Time) error { return nil }); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HeadBucketExtension returns the ECS properties of a bucket.
+func (f *Fake) HeadBucketExtension(input *s3.HeadBucketInput) (*ecs.HeadBucketOutput, error) {
+	return f.HeadBucketExtensionWithContext(aws.BackgroundContext(), input)
+}
+
+// HeadBucketExtensionWithContext is the same as HeadBucketExtension with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) HeadBucketExtensionWithContext(ctx aws.Context, input *s3.HeadBucketInput, opts ...request.Option) (*ecs.HeadBucketOutput, error) {
+	var out *ecs.HeadBucketOutput
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		rec := httptest.NewRecorder()
+		b.writeHeaders(rec)
+		h := rec.Header()
+		out = &ecs.HeadBucketOutput{
+			ComplianceEnabled: headerBoolValue(h, "x-emc-compliance-enabled"),
+			FileSystemAccess:  headerBoolValue(h, "x-emc-file-system-access-enabled"),
+			IsStaleAllowed:    headerBoolValue(h, "x-emc-is-stale-allowed"),
+			ListDuringOutage:  headerBoolValue(h, "x-emc-list-during-outage"),
+			MetadataSearch:    headerString(h, "x-emc-metadata-search"),
+			NameSpace:         headerString(h, "x-emc-namespace"),
+			RetentionPeriod:   headerInt64(h, "x-emc-retention-period"),
+			SSEEnabled:        headerBoolValue(h, "x-emc-server-side-encryption-enabled"),
+			TSOReadOnly:       headerBoolValue(h, "x-emc-tso-read-only"),
+			VPool:             headerString(h, "x-emc-vpool"),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HeadObjectExtension returns the properties of an object.
+func (f *Fake) HeadObjectExtension(input *s3.HeadObjectInput) (*ecs.HeadObjectOutput, error) {
+	return f.HeadObjectExtensionWithContext(aws.BackgroundContext(), input)
+}
+
+// HeadObjectExtensionWithContext is the same as HeadObjectExtension with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) HeadObjectExtensionWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*ecs.HeadObjectOutput, error) {
+	rec, err := f.readObject(ctx, "HEAD", &s3.GetObjectInput{
+		Bucket:            input.Bucket,
+		Key:               input.Key,
+		IfMatch:           input.IfMatch,
+		IfModifiedSince:   input.IfModifiedSince,
+		IfNoneMatch:       input.IfNoneMatch,
+		IfUnmodifiedSince: input.IfUnmodifiedSince,
+		Range:             input.Range,
+	})
+	if err != nil {
+		return nil, err
+	}
+	h := rec.Header()
+	out := &ecs.HeadObjectOutput{
+		AcceptRanges:    headerString(h, "Accept-Ranges"),
+		ContentLength:   headerInt64(h, "Content-Length"),
+		ContentMD5EMC:   headerString(h, "x-emc-content-md5"),
+		ContentType:     headerString(h, "Content-Type"),
+		ETag:            headerString(h, "ETag"),
+		LastModified:    headerTime(h, "Last-Modified"),
+		Metadata:        headerMetadata(h),
+		RetentionPeriod: headerInt64(h, "x-emc-retention-period"),
+		RetentionPolicy: headerString(h, "x-emc-retention-policy"),
+	}
+	return out, nil
+}
+
+// ListBucketMetadataSearch returns the metadata search index of a bucket.
+func (f *Fake) ListBucketMetadataSearch(input *ecs.ListBucketMetadataSearchInput) (*ecs.ListBucketMetadataSearchOutput, error) {
+	return f.ListBucketMetadataSearchWithContext(aws.BackgroundContext(), input)
+}
+
+// ListBucketMetadataSearchWithContext is the same as ListBucketMetadataSearch with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) ListBucketMetadataSearchWithContext(ctx aws.Context, input *ecs.ListBucketMetadataSearchInput, opts ...request.Option) (*ecs.ListBucketMetadataSearchOutput, error) {
+	var out *ecs.ListBucketMetadataSearchOutput
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		out = &ecs.ListBucketMetadataSearchOutput{
+			IndexableKeys:         ecsIndexableKeys(b.indexableKeys()),
+			MetadataSearchEnabled: aws.Bool(b.metadataSearch != nil),
+			OptionalAttributes:    ecsOptionalAttributes(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListBucketQuery returns a page of the objects matching a metadata search
+// query.
+func (f *Fake) ListBucketQuery(input *ecs.ListBucketQueryInput) (*ecs.ListBucketQueryOutput, error) {
+	return f.ListBucketQueryWithContext(aws.BackgroundContext(), input)
+}
+
+// ListBucketQueryWithContext is the same as ListBucketQuery with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) ListBucketQueryWithContext(ctx aws.Context, input *ecs.ListBucketQueryInput, opts ...request.Option) (*ecs.ListBucketQueryOutput, error) {
+	var out *ecs.ListBucketQueryOutput
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		out, err = b.query(input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ListBucketQueryPages iterates over the pages of a ListBucketQuery operation,
+// calling the "fn" function with the response data for each page. To stop
+// iterating, return false from the fn function.
+func (f *Fake) ListBucketQueryPages(input *ecs.ListBucketQueryInput, fn func(*ecs.ListBucketQueryOutput, bool) bool) error {
+	return f.ListBucketQueryPagesWithContext(aws.BackgroundContext(), input, fn)
+}
+
+// ListBucketQueryPagesWithContext same as ListBucketQueryPages except
+// it takes a Context and allows setting request options on the pages.
+func (f *Fake) ListBucketQueryPagesWithContext(ctx aws.Context, input *ecs.ListBucketQueryInput, fn func(*ecs.ListBucketQueryOutput, bool) bool, opts ...request.Option) error {
+	in := *input
+	for {
+		out, err := f.ListBucketQueryWithContext(ctx, &in)
+		if err != nil {
+			return err
+		}
+		last := out.NextMarker == nil
+		if !fn(out, last) || last {
+			return nil
+		}
+		in.Marker = out.NextMarker
+	}
+}
+
+// NewObjectMatchIterator returns an iterator over the object matches of the
+// query described by input.
+func (f *Fake) NewObjectMatchIterator(ctx aws.Context, input *ecs.ListBucketQueryInput, opts ...request.Option) *ecs.ObjectMatchIterator {
+	return ecs.IterateObjectMatches(ctx, f, input)
+}
+
+// ListBucketQueryMatches streams the object matches of the query described by
+// input.
+func (f *Fake) ListBucketQueryMatches(ctx aws.Context, input *ecs.ListBucketQueryInput, opts ...request.Option) (<-chan *ecs.EcsObjectMatch, <-chan error) {
+	return ecs.StreamObjectMatches(ctx, f, input)
+}
+
+// PutBucketIsStaleAllowed sets whether a bucket allows stale reads.
+func (f *Fake) PutBucketIsStaleAllowed(input *ecs.PutBucketIsStaleAllowedInput) (*ecs.PutBucketIsStaleAllowedOutput, error) {
+	return f.PutBucketIsStaleAllowedWithContext(aws.BackgroundContext(), input)
+}
+
+// PutBucketIsStaleAllowedWithContext is the same as PutBucketIsStaleAllowed with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) PutBucketIsStaleAllowedWithContext(ctx aws.Context, input *ecs.PutBucketIsStaleAllowedInput, opts ...request.Option) (*ecs.PutBucketIsStaleAllowedOutput, error) {
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		b.isStaleAllowed = aws.BoolValue(input.IsStaleAllowed)
+		if input.ListDuringOutage != nil {
+			b.listDuringOutage = *input.ListDuringOutage
+		}
+		if input.TSOReadOnly != nil {
+			b.tsoReadOnly = *input.TSOReadOnly
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.PutBucketIsStaleAllowedOutput{}, nil
+}
+
+// PutBucketRetention sets the retention period of a bucket.
+func (f *Fake) PutBucketRetention(input *ecs.PutBucketRetentionInput) (*ecs.PutBucketRetentionOutput, error) {
+	return f.PutBucketRetentionWithContext(aws.BackgroundContext(), input)
+}
+
+// PutBucketRetentionWithContext is the same as PutBucketRetention with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) PutBucketRetentionWithContext(ctx aws.Context, input *ecs.PutBucketRetentionInput, opts ...request.Option) (*ecs.PutBucketRetentionOutput, error) {
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		return b.setRetentionPeriod(aws.Int64Value(input.RetentionPeriod))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.PutBucketRetentionOutput{}, nil
+}
+
+// PutObjectExtension writes an object, or a byte range of it if input sets
+// Range.
+func (f *Fake) PutObjectExtension(input *ecs.PutObjectInput) (*ecs.PutObjectOutput, error) {
+	return f.PutObjectExtensionWithContext(aws.BackgroundContext(), input)
+}
+
+// PutObjectExtensionWithContext is the same as PutObjectExtension with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) PutObjectExtensionWithContext(ctx aws.Context, input *ecs.PutObjectInput, opts ...request.Option) (*ecs.PutObjectOutput, error) {
+	data, err := readBody(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	p := &putObjectParams{
+		data:            data,
+		contentType:     aws.StringValue(input.ContentType),
+		metadata:        userMetadata(input.Metadata),
+		byteRange:       aws.StringValue(input.Range),
+		retentionPeriod: input.RetentionPeriod,
+		retentionPolicy: aws.StringValue(input.RetentionPolicy),
+	}
+
+	out := &ecs.PutObjectOutput{}
+	err = f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		res, err := b.putObject(aws.StringValue(input.Key), p, now)
+		if err != nil {
+			return err
+		}
+		out.AppendOffset = res.appendOffset
+		out.ContentMD5EMC = aws.String(res.object.md5())
+		out.ETag = aws.String(res.object.etag())
+		out.PreviousObjectSize = res.previousSize
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PutObjectRetention sets the retention of an object.
+func (f *Fake) PutObjectRetention(input *ecs.PutObjectRetentionInput) (*ecs.PutObjectRetentionOutput, error) {
+	return f.PutObjectRetentionWithContext(aws.BackgroundContext(), input)
+}
+
+// PutObjectRetentionWithContext is the same as PutObjectRetention with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) PutObjectRetentionWithContext(ctx aws.Context, input *ecs.PutObjectRetentionInput, opts ...request.Option) (*ecs.PutObjectRetentionOutput, error) {
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		o, err := b.object(aws.StringValue(input.Key))
+		if err != nil {
+			return err
+		}
+		return o.setRetention(b, input.RetentionPeriod, aws.StringValue(input.RetentionPolicy), now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ecs.PutObjectRetentionOutput{}, nil
+}
+
+// UpdateObjectRange replaces the bytes of an object starting at offset.
+func (f *Fake) UpdateObjectRange(bucket, key string, offset int64, body io.ReadSeeker) (*ecs.PutObjectOutput, error) {
+	return f.UpdateObjectRangeWithContext(aws.BackgroundContext(), bucket, key, offset, body)
+}
+
+// UpdateObjectRangeWithContext is the same as UpdateObjectRange with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) UpdateObjectRangeWithContext(ctx aws.Context, bucket, key string, offset int64, body io.ReadSeeker, opts ...request.Option) (*ecs.PutObjectOutput, error) {
+	data, err := readRange("UpdateObjectRange", offset, body)
+	if err != nil {
+		return nil, err
+	}
+	return f.putObjectRange(ctx, bucket, key, ecs.UpdateRange(offset, int64(len(data))), data)
+}
+
+// OverwriteObjectRange writes body into an object starting at offset.
+func (f *Fake) OverwriteObjectRange(bucket, key string, offset int64, body io.ReadSeeker) (*ecs.PutObjectOutput, error) {
+	return f.OverwriteObjectRangeWithContext(aws.BackgroundContext(), bucket, key, offset, body)
+}
+
+// OverwriteObjectRangeWithContext is the same as OverwriteObjectRange with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) OverwriteObjectRangeWithContext(ctx aws.Context, bucket, key string, offset int64, body io.ReadSeeker, opts ...request.Option) (*ecs.PutObjectOutput, error) {
+	data, err := readRange("OverwriteObjectRange", offset, body)
+	if err != nil {
+		return nil, err
+	}
+	return f.putObjectRange(ctx, bucket, key, ecs.OverwriteRange(offset), data)
+}
+
+// AppendObject appends body to the end of an object.
+func (f *Fake) AppendObject(bucket, key string, body io.ReadSeeker) (*ecs.PutObjectOutput, error) {
+	return f.AppendObjectWithContext(aws.BackgroundContext(), bucket, key, body)
+}
+
+// AppendObjectWithContext is the same as AppendObject with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) AppendObjectWithContext(ctx aws.Context, bucket, key string, body io.ReadSeeker, opts ...request.Option) (*ecs.PutObjectOutput, error) {
+	if body == nil {
+		invalidParams := request.ErrInvalidParams{Context: "AppendObject"}
+		invalidParams.Add(request.NewErrParamRequired("Body"))
+		return nil, invalidParams
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	return f.putObjectRange(ctx, bucket, key, ecs.AppendRange, data)
+}
+
+func (f *Fake) putObjectRange(ctx aws.Context, bucket, key, byteRange string, data []byte) (*ecs.PutObjectOutput, error) {
+	return f.PutObjectExtensionWithContext(ctx, &ecs.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(data),
+		Range:  aws.String(byteRange),
+	})
+}
+
+// readRange reads the data of a range write from body, starting at its current
+// offset, and validates it the way ecs.S3 does.
+func readRange(op string, offset int64, body io.ReadSeeker) ([]byte, error) {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = ioutil.ReadAll(body); err != nil {
+			return nil, err
+		}
+	}
+	invalidParams := request.ErrInvalidParams{Context: op}
+	if offset < 0 {
+		invalidParams.Add(request.NewErrParamMinValue("Offset", 0))
+	}
+	if len(data) < 1 {
+		invalidParams.Add(request.NewErrParamMinLen("Body", 1))
+	}
+	if invalidParams.Len() > 0 {
+		return nil, invalidParams
+	}
+	return data, nil
+}
+
+func (f *Fake) object(bucket, key string) (*object, error) {
+	b, err := f.bucket(bucket)
+	if err != nil {
+		return nil, err
+	}
+	return b.object(key)
+}
+
+// readObject answers a GET or HEAD object request the way Server does.
+func (f *Fake) readObject(ctx aws.Context, method string, input *s3.GetObjectInput) (*httptest.ResponseRecorder, error) {
+	r := httptest.NewRequest(method, "/", nil)
+	setHeader(r.Header, "Range", input.Range)
+	setHeader(r.Header, "If-Match", input.IfMatch)
+	setHeader(r.Header, "If-None-Match", input.IfNoneMatch)
+	if input.IfModifiedSince != nil {
+		r.Header.Set("If-Modified-Since", input.IfModifiedSince.UTC().Format(http.TimeFormat))
+	}
+	if input.IfUnmodifiedSince != nil {
+		r.Header.Set("If-Unmodified-Since", input.IfUnmodifiedSince.UTC().Format(http.TimeFormat))
+	}
+
+	rec := httptest.NewRecorder()
+	err := f.do(ctx, func(now time.Time) error {
+		o, err := f.object(aws.StringValue(input.Bucket), aws.StringValue(input.Key))
+		if err != nil {
+			return err
+		}
+		o.writeHeaders(rec)
+		http.ServeContent(rec, r, "", o.modified, bytes.NewReader(o.data))
+		switch rec.Code {
+		case http.StatusOK, http.StatusPartialContent:
+			return nil
+		case http.StatusNotModified:
+			return &apiError{rec.Code, "NotModified", "Not Modified"}
+		case http.StatusPreconditionFailed:
+			return &apiError{rec.Code, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold"}
+		case http.StatusRequestedRangeNotSatisfiable:
+			return invalidRange(strings.TrimSpace(rec.Body.String()))
+		}
+		return &apiError{rec.Code, http.StatusText(rec.Code), strings.TrimSpace(rec.Body.String())}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rec, nil
+}
+
+func setHeader(h http.Header, name string, v *string) {
+	if v != nil {
+		h.Set(name, *v)
+	}
+}
+
+func headerString(h http.Header, name string) *string {
+	if v := h.Get(name); v != "" {
+		return aws.String(v)
+	}
+	return nil
+}
+
+func headerInt64(h http.Header, name string) *int64 {
+	if v, err := strconv.ParseInt(h.Get(name), 10, 64); err == nil {
+		return aws.Int64(v)
+	}
+	return nil
+}
+
+func headerBoolValue(h http.Header, name string) *bool {
+	if v, err := strconv.ParseBool(h.Get(name)); err == nil {
+		return aws.Bool(v)
+	}
+	return nil
+}
+
+func headerTime(h http.Header, name string) *time.Time {
+	if t, err := http.ParseTime(h.Get(name)); err == nil {
+		return aws.Time(t)
+	}
+	return nil
+}
+
+// headerMetadata returns the user metadata of h keyed like the SDK does.
+func headerMetadata(h http.Header) map[string]*string {
+	var m map[string]*string
+	for k, v := range h {
+		if strings.HasPrefix(strings.ToLower(k), ecs.UserMetadataPrefix) {
+			if m == nil {
+				m = map[string]*string{}
+			}
+			m[k[len(ecs.UserMetadataPrefix):]] = aws.String(v[0])
+		}
+	}
+	return m
+}
+
+func ecsIndexableKeys(keys []indexableKey) []*ecs.EcsIndexableKey {
+	var out []*ecs.EcsIndexableKey
+	for _, k := range keys {
+		out = append(out, &ecs.EcsIndexableKey{Name: aws.String(k.Name), Datatype: aws.String(k.Datatype)})
+	}
+	return out
+}
+
+func ecsOptionalAttributes() []*ecs.EcsOptionalAttribute {
+	var out []*ecs.EcsOptionalAttribute
+	for _, k := range optionalAttributes {
+		out = append(out, &ecs.EcsOptionalAttribute{Name: aws.String(k.Name), Datatype: aws.String(k.Datatype)})
+	}
+	return out
+}
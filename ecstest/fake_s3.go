@@ -0,0 +1,434 @@
+package ecstest
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// notImplemented returns an ECS client failing every request with the code
+// NotImplemented without sending it. It answers the operations Fake does not
+// implement.
+func notImplemented() *ecs.S3 {
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.AnonymousCredentials,
+		Endpoint:    aws.String("http://ecstest.invalid"),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(0),
+	}))
+	c := ecs.New(s3.New(sess))
+	c.Handlers.Send.Clear()
+	c.Handlers.Send.PushBack(func(r *request.Request) {
+		r.HTTPResponse = &http.Response{
+			StatusCode: http.StatusNotImplemented,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(bytes.NewReader(nil)),
+		}
+		r.Retryable = aws.Bool(false)
+		r.Error = awserr.NewRequestFailure(
+			awserr.New("NotImplemented", "ecstest.Fake does not implement "+r.Operation.Name, nil),
+			http.StatusNotImplemented, "ecstest")
+	})
+	return c
+}
+
+// AbortMultipartUpload aborts a multipart upload.
+func (f *Fake) AbortMultipartUpload(input *s3.AbortMultipartUploadInput) (*s3.AbortMultipartUploadOutput, error) {
+	return f.AbortMultipartUploadWithContext(aws.BackgroundContext(), input)
+}
+
+// AbortMultipartUploadWithContext is the same as AbortMultipartUpload with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) AbortMultipartUploadWithContext(ctx aws.Context, input *s3.AbortMultipartUploadInput, opts ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		return b.abortUpload(aws.StringValue(input.Key), aws.StringValue(input.UploadId))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+// CompleteMultipartUpload writes the object of a multipart upload from its
+// parts.
+func (f *Fake) CompleteMultipartUpload(input *s3.CompleteMultipartUploadInput) (*s3.CompleteMultipartUploadOutput, error) {
+	return f.CompleteMultipartUploadWithContext(aws.BackgroundContext(), input)
+}
+
+// CompleteMultipartUploadWithContext is the same as CompleteMultipartUpload with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) CompleteMultipartUploadWithContext(ctx aws.Context, input *s3.CompleteMultipartUploadInput, opts ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+	var parts []completedPart
+	if input.MultipartUpload != nil {
+		for _, p := range input.MultipartUpload.Parts {
+			parts = append(parts, completedPart{PartNumber: aws.Int64Value(p.PartNumber), ETag: aws.StringValue(p.ETag)})
+		}
+	}
+	out := &s3.CompleteMultipartUploadOutput{Bucket: input.Bucket, Key: input.Key}
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		o, err := b.completeUpload(aws.StringValue(input.Key), aws.StringValue(input.UploadId), parts, now)
+		if err != nil {
+			return err
+		}
+		out.ETag = aws.String(o.etag())
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CopyObject copies an object. The metadata of the source is kept unless
+// MetadataDirective is REPLACE.
+func (f *Fake) CopyObject(input *s3.CopyObjectInput) (*s3.CopyObjectOutput, error) {
+	return f.CopyObjectWithContext(aws.BackgroundContext(), input)
+}
+
+// CopyObjectWithContext is the same as CopyObject with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) CopyObjectWithContext(ctx aws.Context, input *s3.CopyObjectInput, opts ...request.Option) (*s3.CopyObjectOutput, error) {
+	out := &s3.CopyObjectOutput{}
+	err := f.do(ctx, func(now time.Time) error {
+		src, err := f.copySource(aws.StringValue(input.CopySource))
+		if err != nil {
+			return err
+		}
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		p := &putObjectParams{
+			data:        append([]byte(nil), src.data...),
+			contentType: src.contentType,
+			metadata:    src.metadata,
+		}
+		if aws.StringValue(input.MetadataDirective) == s3.MetadataDirectiveReplace {
+			p.contentType = aws.StringValue(input.ContentType)
+			p.metadata = userMetadata(input.Metadata)
+		}
+		res, err := b.putObject(aws.StringValue(input.Key), p, now)
+		if err != nil {
+			return err
+		}
+		out.CopyObjectResult = &s3.CopyObjectResult{
+			ETag:         aws.String(res.object.etag()),
+			LastModified: aws.Time(res.object.modified.UTC().Truncate(time.Second)),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// copySource returns the object named by the CopySource of a copy request,
+// "bucket/key" with an optional leading slash and versionId.
+func (f *Fake) copySource(source string) (*object, error) {
+	source = strings.TrimPrefix(source, "/")
+	if i := strings.Index(source, "?"); i >= 0 {
+		source = source[:i]
+	}
+	source, err := url.PathUnescape(source)
+	i := strings.Index(source, "/")
+	if err != nil || i < 0 {
+		return nil, invalidArgument("Invalid copy source")
+	}
+	return f.object(source[:i], source[i+1:])
+}
+
+// CreateBucket creates a bucket without ECS properties.
+func (f *Fake) CreateBucket(input *s3.CreateBucketInput) (*s3.CreateBucketOutput, error) {
+	return f.CreateBucketWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateBucketWithContext is the same as CreateBucket with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) CreateBucketWithContext(ctx aws.Context, input *s3.CreateBucketInput, opts ...request.Option) (*s3.CreateBucketOutput, error) {
+	return f.CreateBucketExtensionWithContext(ctx, &ecs.CreateBucketInput{Bucket: input.Bucket})
+}
+
+// CreateMultipartUpload starts a multipart upload. The ECS headers set by
+// opts, such as the ones ecs.Uploader adds, apply to the object written.
+func (f *Fake) CreateMultipartUpload(input *s3.CreateMultipartUploadInput) (*s3.CreateMultipartUploadOutput, error) {
+	return f.CreateMultipartUploadWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateMultipartUploadWithContext is the same as CreateMultipartUpload with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) CreateMultipartUploadWithContext(ctx aws.Context, input *s3.CreateMultipartUploadInput, opts ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+	r := httptest.NewRequest("POST", "/", nil)
+	setHeader(r.Header, "Content-Type", input.ContentType)
+	for k, v := range input.Metadata {
+		setHeader(r.Header, ecs.UserMetadataPrefix+k, v)
+	}
+	(&request.Request{HTTPRequest: r}).ApplyOptions(opts...)
+
+	out := &s3.CreateMultipartUploadOutput{Bucket: input.Bucket, Key: input.Key}
+	err := f.do(ctx, func(now time.Time) error {
+		p, err := putParams(r)
+		if err != nil {
+			return err
+		}
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		id, err := b.initiateUpload(aws.StringValue(input.Key), p)
+		if err != nil {
+			return err
+		}
+		out.UploadId = aws.String(id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetObject reads an object, see GetObjectExtension.
+func (f *Fake) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	return f.GetObjectWithContext(aws.BackgroundContext(), input)
+}
+
+// GetObjectWithContext is the same as GetObject with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) GetObjectWithContext(ctx aws.Context, input *s3.GetObjectInput, opts ...request.Option) (*s3.GetObjectOutput, error) {
+	out, err := f.GetObjectExtensionWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &s3.GetObjectOutput{
+		AcceptRanges:  out.AcceptRanges,
+		Body:          out.Body,
+		ContentLength: out.ContentLength,
+		ContentRange:  out.ContentRange,
+		ContentType:   out.ContentType,
+		ETag:          out.ETag,
+		LastModified:  out.LastModified,
+		Metadata:      out.Metadata,
+	}, nil
+}
+
+// HeadBucket checks that a bucket exists.
+func (f *Fake) HeadBucket(input *s3.HeadBucketInput) (*s3.HeadBucketOutput, error) {
+	return f.HeadBucketWithContext(aws.BackgroundContext(), input)
+}
+
+// HeadBucketWithContext is the same as HeadBucket with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) HeadBucketWithContext(ctx aws.Context, input *s3.HeadBucketInput, opts ...request.Option) (*s3.HeadBucketOutput, error) {
+	if _, err := f.HeadBucketExtensionWithContext(ctx, input); err != nil {
+		return nil, err
+	}
+	return &s3.HeadBucketOutput{}, nil
+}
+
+// HeadObject returns the properties of an object, see HeadObjectExtension.
+func (f *Fake) HeadObject(input *s3.HeadObjectInput) (*s3.HeadObjectOutput, error) {
+	return f.HeadObjectWithContext(aws.BackgroundContext(), input)
+}
+
+// HeadObjectWithContext is the same as HeadObject with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) HeadObjectWithContext(ctx aws.Context, input *s3.HeadObjectInput, opts ...request.Option) (*s3.HeadObjectOutput, error) {
+	out, err := f.HeadObjectExtensionWithContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	return &s3.HeadObjectOutput{
+		AcceptRanges:  out.AcceptRanges,
+		ContentLength: out.ContentLength,
+		ContentType:   out.ContentType,
+		ETag:          out.ETag,
+		LastModified:  out.LastModified,
+		Metadata:      out.Metadata,
+	}, nil
+}
+
+// ListObjects returns a page of the objects of a bucket in key order.
+// Delimiter is not supported.
+func (f *Fake) ListObjects(input *s3.ListObjectsInput) (*s3.ListObjectsOutput, error) {
+	return f.ListObjectsWithContext(aws.BackgroundContext(), input)
+}
+
+// ListObjectsWithContext is the same as ListObjects with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) ListObjectsWithContext(ctx aws.Context, input *s3.ListObjectsInput, opts ...request.Option) (*s3.ListObjectsOutput, error) {
+	maxKeys := 1000
+	if input.MaxKeys != nil && *input.MaxKeys >= 0 {
+		maxKeys = int(*input.MaxKeys)
+	}
+	var res *listBucketResult
+	err := f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		res = b.listObjects(aws.StringValue(input.Prefix), aws.StringValue(input.Marker), maxKeys)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := &s3.ListObjectsOutput{
+		IsTruncated: aws.Bool(res.IsTruncated),
+		Marker:      aws.String(res.Marker),
+		MaxKeys:     aws.Int64(int64(res.MaxKeys)),
+		Name:        aws.String(res.Name),
+		Prefix:      aws.String(res.Prefix),
+	}
+	if res.NextMarker != "" {
+		out.NextMarker = aws.String(res.NextMarker)
+	}
+	for _, e := range res.Contents {
+		out.Contents = append(out.Contents, &s3.Object{
+			ETag:         aws.String(e.ETag),
+			Key:          aws.String(e.Key),
+			LastModified: aws.Time(e.LastModified.Truncate(time.Second)),
+			Size:         aws.Int64(e.Size),
+			StorageClass: aws.String(e.StorageClass),
+		})
+	}
+	return out, nil
+}
+
+// ListObjectsPages iterates over the pages of a ListObjects operation,
+// calling the "fn" function with the response data for each page. To stop
+// iterating, return false from the fn function.
+func (f *Fake) ListObjectsPages(input *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool) error {
+	return f.ListObjectsPagesWithContext(aws.BackgroundContext(), input, fn)
+}
+
+// ListObjectsPagesWithContext same as ListObjectsPages except
+// it takes a Context and allows setting request options on the pages.
+func (f *Fake) ListObjectsPagesWithContext(ctx aws.Context, input *s3.ListObjectsInput, fn func(*s3.ListObjectsOutput, bool) bool, opts ...request.Option) error {
+	in := *input
+	for {
+		out, err := f.ListObjectsWithContext(ctx, &in)
+		if err != nil {
+			return err
+		}
+		last := !aws.BoolValue(out.IsTruncated)
+		if !fn(out, last) || last {
+			return nil
+		}
+		in.Marker = out.NextMarker
+	}
+}
+
+// PutObject writes an object.
+func (f *Fake) PutObject(input *s3.PutObjectInput) (*s3.PutObjectOutput, error) {
+	return f.PutObjectWithContext(aws.BackgroundContext(), input)
+}
+
+// PutObjectWithContext is the same as PutObject with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	data, err := readBody(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	out, err := f.PutObjectExtensionWithContext(ctx, &ecs.PutObjectInput{
+		Body:        bytes.NewReader(data),
+		Bucket:      input.Bucket,
+		ContentType: input.ContentType,
+		Key:         input.Key,
+		Metadata:    input.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &s3.PutObjectOutput{ETag: out.ETag}, nil
+}
+
+// UploadPart stores a part of a multipart upload.
+func (f *Fake) UploadPart(input *s3.UploadPartInput) (*s3.UploadPartOutput, error) {
+	return f.UploadPartWithContext(aws.BackgroundContext(), input)
+}
+
+// UploadPartWithContext is the same as UploadPart with the addition of
+// the ability to pass a context and additional request options.
+func (f *Fake) UploadPartWithContext(ctx aws.Context, input *s3.UploadPartInput, opts ...request.Option) (*s3.UploadPartOutput, error) {
+	data, err := readBody(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	out := &s3.UploadPartOutput{}
+	err = f.do(ctx, func(now time.Time) error {
+		b, err := f.bucket(aws.StringValue(input.Bucket))
+		if err != nil {
+			return err
+		}
+		etag, err := b.uploadPart(aws.StringValue(input.Key), aws.StringValue(input.UploadId), aws.Int64Value(input.PartNumber), data)
+		if err != nil {
+			return err
+		}
+		out.ETag = aws.String(etag)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// readBody reads the data of a request from body, starting at its start like
+// the SDK sends it.
+func readBody(body io.ReadSeeker) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	if _, err := body.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(body)
+}
+
+// userMetadata returns metadata keyed by the lower case x-amz-meta- header
+// name.
+func userMetadata(metadata map[string]*string) map[string]string {
+	m := map[string]string{}
+	for k, v := range metadata {
+		m[ecs.UserMetadataPrefix+strings.ToLower(k)] = aws.StringValue(v)
+	}
+	return m
+}
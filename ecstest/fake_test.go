@@ -0,0 +1,278 @@
+package ecstest_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecsiface"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// forEachImplementation runs test against a client of a Server and against a
+// Fake, so that both behave the same.
+func forEachImplementation(t *testing.T, test func(t *testing.T, client ecsiface.ECSAPI, setNow func(func() time.Time))) {
+	t.Run("Server", func(t *testing.T) {
+		server := ecstest.NewServer()
+		defer server.Close()
+		test(t, server.Client(), func(now func() time.Time) { server.Now = now })
+	})
+	t.Run("Fake", func(t *testing.T) {
+		fake := ecstest.NewFake()
+		test(t, fake, func(now func() time.Time) { fake.Now = now })
+	})
+}
+
+func TestObjectRangeSeekedBody(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, client ecsiface.ECSAPI, setNow func(func() time.Time)) {
+		_, err := client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+		assert.Nil(t, err)
+		putObject(t, client, "b", "k", "hello", nil)
+
+		// Only the data after the offset of the body is written.
+		body := strings.NewReader("xxELL")
+		body.Seek(2, io.SeekStart)
+		_, err = client.UpdateObjectRange("b", "k", 1, body)
+		assert.Nil(t, err)
+		body = strings.NewReader("xx world")
+		body.Seek(2, io.SeekStart)
+		_, err = client.AppendObject("b", "k", body)
+		assert.Nil(t, err)
+		body = strings.NewReader("x!")
+		body.Seek(1, io.SeekStart)
+		_, err = client.OverwriteObjectRange("b", "k", 11, body)
+		assert.Nil(t, err)
+		assert.Equal(t, "hELLo world!", getObject(t, client, "b", "k"))
+
+		body = strings.NewReader("x")
+		body.Seek(1, io.SeekStart)
+		_, err = client.OverwriteObjectRange("b", "k", 0, body)
+		assert.IsType(t, request.ErrInvalidParams{}, err)
+	})
+}
+
+func TestFakeObject(t *testing.T) {
+	fake := ecstest.NewFake()
+	_, err := fake.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+
+	put, err := fake.PutObjectExtension(&ecs.PutObjectInput{
+		Bucket:      aws.String("b"),
+		Key:         aws.String("k"),
+		Body:        strings.NewReader("0123456789"),
+		ContentType: aws.String("text/plain"),
+		Metadata:    aws.StringMap(map[string]string{"Color": "red"}),
+	})
+	assert.Nil(t, err)
+
+	head, err := fake.HeadObjectExtension(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(10), aws.Int64Value(head.ContentLength))
+	assert.Equal(t, "text/plain", aws.StringValue(head.ContentType))
+	assert.Equal(t, aws.StringValue(put.ETag), aws.StringValue(head.ETag))
+	assert.Equal(t, "red", aws.StringValue(head.Metadata["Color"]))
+
+	_, err = fake.GetObjectExtension(&s3.GetObjectInput{
+		Bucket:  aws.String("b"),
+		Key:     aws.String("k"),
+		IfMatch: aws.String(`"other"`),
+	})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "PreconditionFailed", err.(awserr.Error).Code())
+	}
+
+	out, err := fake.GetObjectRanges(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")},
+		[]ecs.ByteRange{{Offset: 1, Length: 2}, {Offset: 7, Length: 3}})
+	if assert.Nil(t, err) {
+		var parts []string
+		for out.Next() {
+			data, _ := ioutil.ReadAll(out.Part().Body)
+			parts = append(parts, strconv.FormatInt(out.Part().Offset, 10)+":"+string(data))
+		}
+		assert.Nil(t, out.Err())
+		assert.Equal(t, []string{"1:12", "7:789"}, parts)
+	}
+	for _, ranges := range [][]ecs.ByteRange{nil, {{Offset: -1, Length: 2}}, {{Offset: 1, Length: 0}}} {
+		_, err = fake.GetObjectRanges(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")}, ranges)
+		assert.IsType(t, request.ErrInvalidParams{}, err, ranges)
+	}
+
+	_, err = fake.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.Nil(t, err)
+	_, err = fake.GetObjectExtension(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "NoSuchKey", err.(awserr.Error).Code())
+	}
+	_, err = fake.DeleteBucket(&s3.DeleteBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+}
+
+func TestFakeObjectMatchIterator(t *testing.T) {
+	fake := ecstest.NewFake()
+	_, err := fake.CreateBucketExtension(&ecs.CreateBucketInput{
+		Bucket:         aws.String("b"),
+		MetadataSearch: aws.String("Size"),
+	})
+	assert.Nil(t, err)
+	for i := 0; i < 5; i++ {
+		putObject(t, fake, "b", strconv.Itoa(i), strings.Repeat("x", i), nil)
+	}
+
+	it := fake.NewObjectMatchIterator(context.Background(), &ecs.ListBucketQueryInput{
+		Bucket:  aws.String("b"),
+		Query:   aws.String("Size>0"),
+		MaxKeys: aws.Int64(2),
+	})
+	var names []string
+	for it.Next() {
+		names = append(names, aws.StringValue(it.Match().ObjectName))
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"1", "2", "3", "4"}, names)
+
+	info, err := fake.GetBucketInfo("b")
+	assert.Nil(t, err)
+	assert.True(t, info.MetadataSearchEnabled())
+}
+
+func TestFakeContext(t *testing.T) {
+	fake := ecstest.NewFake()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := fake.CreateBucketExtensionWithContext(ctx, &ecs.CreateBucketInput{Bucket: aws.String("b")})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, request.CanceledErrorCode, err.(awserr.Error).Code())
+	}
+}
+
+// forwardingClient records the plain S3 operations a Fake forwards.
+type forwardingClient struct {
+	ecsiface.ECSAPI
+	buckets []string
+}
+
+func (c *forwardingClient) PutBucketVersioning(input *s3.PutBucketVersioningInput) (*s3.PutBucketVersioningOutput, error) {
+	c.buckets = append(c.buckets, aws.StringValue(input.Bucket))
+	return &s3.PutBucketVersioningOutput{}, nil
+}
+
+func TestFakeForwarding(t *testing.T) {
+	fake := ecstest.NewFake()
+	input := &s3.PutBucketVersioningInput{
+		Bucket:                  aws.String("b"),
+		VersioningConfiguration: &s3.VersioningConfiguration{Status: aws.String(s3.BucketVersioningStatusEnabled)},
+	}
+	_, err := fake.PutBucketVersioning(input)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "NotImplemented", err.(awserr.Error).Code())
+		assert.Equal(t, 501, err.(awserr.RequestFailure).StatusCode())
+	}
+
+	client := &forwardingClient{}
+	fake.ECSAPI = client
+	_, err = fake.PutBucketVersioning(input)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, client.buckets)
+}
+
+func TestFakeS3(t *testing.T) {
+	fake := ecstest.NewFake()
+	_, err := fake.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	_, err = fake.HeadBucket(&s3.HeadBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+
+	_, err = fake.PutObject(&s3.PutObjectInput{
+		Bucket:   aws.String("b"),
+		Key:      aws.String("k1"),
+		Body:     strings.NewReader("data"),
+		Metadata: map[string]*string{"Color": aws.String("red")},
+	})
+	assert.Nil(t, err)
+	_, err = fake.CopyObject(&s3.CopyObjectInput{Bucket: aws.String("b"), Key: aws.String("k2"), CopySource: aws.String("b/k1")})
+	assert.Nil(t, err)
+
+	head, err := fake.HeadObject(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k2")})
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(4), aws.Int64Value(head.ContentLength))
+		assert.Equal(t, "red", aws.StringValue(head.Metadata["Color"]))
+	}
+	get, err := fake.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k2"), Range: aws.String("bytes=1-2")})
+	if assert.Nil(t, err) {
+		data, _ := ioutil.ReadAll(get.Body)
+		assert.Equal(t, "at", string(data))
+	}
+
+	var keys []string
+	err = fake.ListObjectsPages(&s3.ListObjectsInput{Bucket: aws.String("b"), MaxKeys: aws.Int64(1)}, func(out *s3.ListObjectsOutput, last bool) bool {
+		for _, o := range out.Contents {
+			keys = append(keys, aws.StringValue(o.Key))
+		}
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"k1", "k2"}, keys)
+}
+
+func TestFakeMultipartUpload(t *testing.T) {
+	fake := ecstest.NewFake()
+	_, err := fake.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+
+	data := bytes.Repeat([]byte("0123456789"), int(ecs.MinUploadPartSize/10)+100)
+	out, err := ecs.NewUploader(fake).Upload(&ecs.PutObjectInput{
+		Bucket:          aws.String("b"),
+		Key:             aws.String("k"),
+		Body:            bytes.NewReader(data),
+		RetentionPeriod: aws.Int64(3600),
+	})
+	if assert.Nil(t, err) {
+		assert.NotEqual(t, "", out.UploadID)
+	}
+	assert.Equal(t, string(data), getObject(t, fake, "b", "k"))
+	retention, err := fake.GetObjectRetention(&ecs.GetObjectRetentionInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(3600), aws.Int64Value(retention.RetentionPeriod))
+	}
+
+	created, err := fake.CreateMultipartUpload(&s3.CreateMultipartUploadInput{Bucket: aws.String("b"), Key: aws.String("other")})
+	assert.Nil(t, err)
+	_, err = fake.AbortMultipartUpload(&s3.AbortMultipartUploadInput{Bucket: aws.String("b"), Key: aws.String("other"), UploadId: created.UploadId})
+	assert.Nil(t, err)
+	_, err = fake.UploadPart(&s3.UploadPartInput{
+		Bucket:     aws.String("b"),
+		Key:        aws.String("other"),
+		UploadId:   created.UploadId,
+		PartNumber: aws.Int64(1),
+		Body:       strings.NewReader("data"),
+	})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "NoSuchUpload", err.(awserr.Error).Code())
+	}
+}
@@ -0,0 +1,198 @@
+package ecstest
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// A multipartUpload is a multipart upload in progress. The object is written
+// with params when the upload completes.
+type multipartUpload struct {
+	key    string
+	params *putObjectParams
+	parts  map[int64][]byte
+}
+
+var (
+	errNoSuchUpload = &apiError{http.StatusNotFound, "NoSuchUpload", "The specified multipart upload does not exist."}
+	errInvalidPart  = &apiError{http.StatusBadRequest, "InvalidPart", "One or more of the specified parts could not be found."}
+)
+
+func partETag(data []byte) string {
+	sum := md5.Sum(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// initiateUpload starts a multipart upload of key and returns its ID.
+func (b *bucket) initiateUpload(key string, p *putObjectParams) (string, error) {
+	if p.byteRange != "" {
+		return "", invalidArgument("Range is not supported by multipart uploads")
+	}
+	if p.retentionPeriod != nil && *p.retentionPeriod < 0 {
+		return "", errInvalidRetentionPeriod
+	}
+	b.lastUploadID++
+	id := strconv.Itoa(b.lastUploadID)
+	b.uploads[id] = &multipartUpload{key: key, params: p, parts: map[int64][]byte{}}
+	return id, nil
+}
+
+func (b *bucket) upload(key, id string) (*multipartUpload, error) {
+	u := b.uploads[id]
+	if u == nil || u.key != key {
+		return nil, errNoSuchUpload
+	}
+	return u, nil
+}
+
+// uploadPart stores part number n of the upload id and returns its ETag.
+func (b *bucket) uploadPart(key, id string, n int64, data []byte) (string, error) {
+	u, err := b.upload(key, id)
+	if err != nil {
+		return "", err
+	}
+	if n < 1 || n > 10000 {
+		return "", invalidArgument("Part number must be an integer between 1 and 10000, inclusive")
+	}
+	u.parts[n] = data
+	return partETag(data), nil
+}
+
+type completedPart struct {
+	PartNumber int64
+	ETag       string
+}
+
+// completeUpload writes the object of the upload id from parts, which must be
+// in ascending order and match the uploaded parts.
+func (b *bucket) completeUpload(key, id string, parts []completedPart, now time.Time) (*object, error) {
+	u, err := b.upload(key, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return nil, &apiError{http.StatusBadRequest, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema."}
+	}
+	var data []byte
+	for i, part := range parts {
+		if i > 0 && part.PartNumber <= parts[i-1].PartNumber {
+			return nil, &apiError{http.StatusBadRequest, "InvalidPartOrder", "The list of parts was not in ascending order."}
+		}
+		d, ok := u.parts[part.PartNumber]
+		if !ok || part.ETag != partETag(d) {
+			return nil, errInvalidPart
+		}
+		data = append(data, d...)
+	}
+
+	p := *u.params
+	p.data = data
+	res, err := b.putObject(key, &p, now)
+	if err != nil {
+		return nil, err
+	}
+	delete(b.uploads, id)
+	return res.object, nil
+}
+
+func (b *bucket) abortUpload(key, id string) error {
+	if _, err := b.upload(key, id); err != nil {
+		return err
+	}
+	delete(b.uploads, id)
+	return nil
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Bucket   string
+	Key      string
+	UploadID string `xml:"UploadId"`
+}
+
+type completeMultipartUpload struct {
+	Parts []completedPart `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+	Bucket  string
+	Key     string
+	ETag    string
+}
+
+func (s *Server) serveMultipartUpload(w http.ResponseWriter, r *http.Request, b *bucket, key string, now time.Time) {
+	query := r.URL.Query()
+	id := query.Get("uploadId")
+	switch {
+	case r.Method == "POST" && id == "":
+		p, err := putParams(r)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		if id, err = b.initiateUpload(key, p); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		writeXML(w, &initiateMultipartUploadResult{Bucket: b.name, Key: key, UploadID: id})
+	case r.Method == "PUT":
+		n, err := strconv.ParseInt(query.Get("partNumber"), 10, 64)
+		if err != nil {
+			writeAPIError(w, invalidArgument("Invalid partNumber"))
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		etag, err := b.uploadPart(key, id, n, body)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		w.Header().Set("ETag", etag)
+	case r.Method == "POST":
+		var in completeMultipartUpload
+		if err := xml.NewDecoder(r.Body).Decode(&in); err != nil {
+			writeAPIError(w, invalidArgument(err.Error()))
+			return
+		}
+		o, err := b.completeUpload(key, id, in.Parts, now)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		w.Header().Set("x-emc-content-md5", o.md5())
+		writeXML(w, &completeMultipartUploadResult{Bucket: b.name, Key: key, ETag: o.etag()})
+	case r.Method == "DELETE":
+		if err := b.abortUpload(key, id); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+	}
+}
@@ -0,0 +1,344 @@
+package ecstest
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+)
+
+type object struct {
+	data        []byte
+	contentType string
+	// metadata is keyed by the lower case x-amz-meta- header name.
+	metadata map[string]string
+	created  time.Time
+	modified time.Time
+
+	retentionPeriod *int64
+	retentionPolicy string
+}
+
+func (o *object) md5() string {
+	sum := md5.Sum(o.data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (o *object) etag() string {
+	return `"` + o.md5() + `"`
+}
+
+// retentionExpiry returns when the retention of o ends. The retention period
+// of the object takes precedence over the one of its bucket.
+func (o *object) retentionExpiry(b *bucket) time.Time {
+	period := b.retentionPeriod
+	if o.retentionPeriod != nil {
+		period = *o.retentionPeriod
+	}
+	return ecs.RetentionExpiresAt(o.modified, period)
+}
+
+func (o *object) underRetention(b *bucket, now time.Time) bool {
+	return now.Before(o.retentionExpiry(b))
+}
+
+func (b *bucket) object(key string) (*object, error) {
+	o := b.objects[key]
+	if o == nil {
+		return nil, errNoSuchKey
+	}
+	return o, nil
+}
+
+// putObjectParams describes a PUT object request.
+type putObjectParams struct {
+	data        []byte
+	contentType string
+	// metadata is keyed by the lower case x-amz-meta- header name.
+	metadata map[string]string
+	// byteRange is the Range of a byte range update or append, if any.
+	byteRange       string
+	retentionPeriod *int64
+	retentionPolicy string
+}
+
+type putObjectResult struct {
+	object *object
+	// previousSize is the size of the object before a byte range update.
+	previousSize *int64
+	// appendOffset is the offset an append wrote the data at.
+	appendOffset *int64
+}
+
+func (b *bucket) putObject(key string, p *putObjectParams, now time.Time) (*putObjectResult, error) {
+	if p.retentionPeriod != nil && *p.retentionPeriod < 0 {
+		return nil, errInvalidRetentionPeriod
+	}
+	o := b.objects[key]
+	if o != nil && o.underRetention(b, now) {
+		return nil, errObjectUnderRetention
+	}
+
+	res := &putObjectResult{}
+	if p.byteRange == "" {
+		o = &object{data: p.data, contentType: p.contentType, created: now, metadata: map[string]string{}}
+		if o.contentType == "" {
+			o.contentType = "binary/octet-stream"
+		}
+		for k, v := range p.metadata {
+			o.metadata[k] = v
+		}
+	} else {
+		if o == nil {
+			return nil, errNoSuchKey
+		}
+		previous := int64(len(o.data))
+		data, appendOffset, err := writeRange(o.data, p.byteRange, p.data)
+		if err != nil {
+			return nil, invalidRange(err.Error())
+		}
+		o.data = data
+		res.previousSize = &previous
+		if appendOffset >= 0 {
+			res.appendOffset = &appendOffset
+		}
+	}
+
+	if p.retentionPeriod != nil {
+		o.retentionPeriod = p.retentionPeriod
+	}
+	if p.retentionPolicy != "" {
+		o.retentionPolicy = p.retentionPolicy
+	}
+	o.modified = now
+	b.objects[key] = o
+	res.object = o
+	return res, nil
+}
+
+// deleteObject deletes the object key, if any.
+func (b *bucket) deleteObject(key string, now time.Time) error {
+	o := b.objects[key]
+	if o == nil {
+		return nil
+	}
+	if o.underRetention(b, now) {
+		return errObjectUnderRetention
+	}
+	delete(b.objects, key)
+	return nil
+}
+
+// setRetention updates the retention of o. The expiry of a retention that
+// has not ended yet cannot be moved closer.
+func (o *object) setRetention(b *bucket, period *int64, policy string, now time.Time) error {
+	if period != nil {
+		if *period < 0 {
+			return errInvalidRetentionPeriod
+		}
+		expiry := o.retentionExpiry(b)
+		if now.Before(expiry) && ecs.RetentionExpiresAt(o.modified, *period).Before(expiry) {
+			return retentionReduction("The retention period of the object cannot be reduced")
+		}
+		o.retentionPeriod = period
+	}
+	if policy != "" {
+		o.retentionPolicy = policy
+	}
+	return nil
+}
+
+func (o *object) writeHeaders(w http.ResponseWriter) {
+	h := w.Header()
+	h.Set("ETag", o.etag())
+	h.Set("Last-Modified", o.modified.UTC().Format(http.TimeFormat))
+	h.Set("Content-Type", o.contentType)
+	h.Set("x-emc-content-md5", o.md5())
+	for k, v := range o.metadata {
+		h.Set(k, v)
+	}
+	if o.retentionPeriod != nil {
+		h.Set("x-emc-retention-period", strconv.FormatInt(*o.retentionPeriod, 10))
+	}
+	if o.retentionPolicy != "" {
+		h.Set("x-emc-retention-policy", o.retentionPolicy)
+	}
+}
+
+func (s *Server) serveObject(w http.ResponseWriter, r *http.Request, bucketName, key string) {
+	b, err := s.bucket(bucketName)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	now := s.Now()
+	query := r.URL.Query()
+	_, retention := query["retention"]
+	if _, uploads := query["uploads"]; uploads || query.Get("uploadId") != "" {
+		s.serveMultipartUpload(w, r, b, key, now)
+		return
+	}
+	switch {
+	case r.Method == "PUT" && retention:
+		o, err := b.object(key)
+		if err == nil {
+			var period *int64
+			if period, err = headerInt(r, "x-emc-retention-period"); err == nil {
+				err = o.setRetention(b, period, r.Header.Get("x-emc-retention-policy"), now)
+			}
+		}
+		writeAPIError(w, err)
+	case r.Method == "PUT":
+		s.putObject(w, r, b, key, now)
+	case r.Method == "DELETE":
+		if err := b.deleteObject(key, now); err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "GET" || r.Method == "HEAD":
+		o, err := b.object(key)
+		if err != nil {
+			writeAPIError(w, err)
+			return
+		}
+		o.writeHeaders(w)
+		http.ServeContent(w, r, "", o.modified, bytes.NewReader(o.data))
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+	}
+}
+
+// headerInt returns the integer value of the header name, nil if it is not
+// set.
+func headerInt(r *http.Request, name string) (*int64, error) {
+	v := r.Header.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return nil, invalidArgument("Invalid " + name + " header")
+	}
+	return &n, nil
+}
+
+func (s *Server) putObject(w http.ResponseWriter, r *http.Request, b *bucket, key string, now time.Time) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+		return
+	}
+	if v := r.Header.Get("Content-MD5"); v != "" {
+		sum := md5.Sum(body)
+		if v != base64.StdEncoding.EncodeToString(sum[:]) {
+			writeError(w, http.StatusBadRequest, "BadDigest", "The Content-MD5 you specified did not match what was received.")
+			return
+		}
+	}
+	p, err := putParams(r)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	p.data = body
+
+	res, err := b.putObject(key, p, now)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+	h := w.Header()
+	h.Set("ETag", res.object.etag())
+	h.Set("x-emc-content-md5", res.object.md5())
+	if res.previousSize != nil {
+		h.Set("x-emc-previous-object-size", strconv.FormatInt(*res.previousSize, 10))
+	}
+	if res.appendOffset != nil {
+		h.Set("x-emc-append-offset", strconv.FormatInt(*res.appendOffset, 10))
+	}
+}
+
+// putParams returns the parameters of the PUT object or initiate multipart
+// upload request r, without the data.
+func putParams(r *http.Request) (*putObjectParams, error) {
+	p := &putObjectParams{
+		contentType:     r.Header.Get("Content-Type"),
+		metadata:        map[string]string{},
+		byteRange:       r.Header.Get("Range"),
+		retentionPolicy: r.Header.Get("x-emc-retention-policy"),
+	}
+	for k, v := range r.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, ecs.UserMetadataPrefix) {
+			p.metadata[k] = v[0]
+		}
+	}
+	var err error
+	p.retentionPeriod, err = headerInt(r, "x-emc-retention-period")
+	return p, err
+}
+
+// writeRange applies a Range PUT to data. It returns the new data and, for
+// appends, the offset the body was written at or -1.
+func writeRange(data []byte, rng string, body []byte) ([]byte, int64, error) {
+	if rng == ecs.AppendRange {
+		return append(data, body...), int64(len(data)), nil
+	}
+
+	spec := strings.TrimPrefix(rng, "bytes=")
+	dash := strings.Index(spec, "-")
+	if spec == rng || dash < 0 {
+		return nil, -1, fmt.Errorf("invalid range %q", rng)
+	}
+	first, err := strconv.ParseInt(spec[:dash], 10, 64)
+	if err != nil || first < 0 {
+		return nil, -1, fmt.Errorf("invalid range %q", rng)
+	}
+	size := int64(len(data))
+
+	if spec[dash+1:] == "" {
+		// Overwrite from first, zero filling any gap after the object.
+		end := first + int64(len(body))
+		if end > size {
+			data = append(data, make([]byte, end-size)...)
+		}
+		copy(data[first:], body)
+		return data, -1, nil
+	}
+
+	last, err := strconv.ParseInt(spec[dash+1:], 10, 64)
+	if err != nil || last < first {
+		return nil, -1, fmt.Errorf("invalid range %q", rng)
+	}
+	if last >= size {
+		return nil, -1, fmt.Errorf("range %q is outside of the object of %d bytes", rng, size)
+	}
+	if int64(len(body)) != last-first+1 {
+		return nil, -1, fmt.Errorf("range %q does not match the body of %d bytes", rng, len(body))
+	}
+	copy(data[first:], body)
+	return data, -1, nil
+}
@@ -0,0 +1,162 @@
+package ecstest
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/mdsearch"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// lastPageMarker is the NextMarker of the last page of query results.
+const lastPageMarker = "NO MORE PAGES"
+
+type bucketQueryResult struct {
+	XMLName       xml.Name `xml:"BucketQueryResult"`
+	Name          string
+	Marker        string
+	NextMarker    string
+	MaxKeys       int64
+	ObjectMatches []objectMatch `xml:"ObjectMatches>object"`
+}
+
+type objectMatch struct {
+	ObjectName string     `xml:"objectName"`
+	ObjectID   string     `xml:"objectId"`
+	VersionID  string     `xml:"versionId"`
+	QueryMds   []queryMds `xml:"queryMds"`
+}
+
+type queryMds struct {
+	Type  string    `xml:"type"`
+	MdMap []mdEntry `xml:"mdMap>entry"`
+}
+
+type mdEntry struct {
+	Key   string `xml:"key"`
+	Value string `xml:"value"`
+}
+
+// query returns the page of the objects of b matching the query of input.
+func (b *bucket) query(input *ecs.ListBucketQueryInput) (*ecs.ListBucketQueryOutput, error) {
+	if b.metadataSearch == nil {
+		return nil, errMetadataSearchNotEnabled
+	}
+	objects := make([]*mdsearch.Object, 0, len(b.objects))
+	for _, key := range objectKeys(b.objects) {
+		objects = append(objects, b.searchObject(key))
+	}
+	s := mdsearch.NewSchema(ecsIndexableKeys(b.indexableKeys()))
+	out, err := mdsearch.Evaluate(input, s, objects)
+	if err != nil {
+		return nil, invalidQuery(err.Error())
+	}
+	return out, nil
+}
+
+// searchObject returns the object key as seen by a query.
+func (b *bucket) searchObject(key string) *mdsearch.Object {
+	o := b.objects[key]
+	id := md5.Sum([]byte(b.name + "/" + key))
+	so := &mdsearch.Object{
+		Name:         key,
+		ObjectId:     hex.EncodeToString(id[:]),
+		VersionId:    "0",
+		Size:         int64(len(o.data)),
+		CreateTime:   o.created,
+		LastModified: o.modified,
+		Owner:        "ecstest",
+		ContentType:  o.contentType,
+		Retention:    int64(o.retentionExpiry(b).Sub(o.modified) / time.Second),
+		Metadata:     map[string]string{},
+	}
+	for k, v := range o.metadata {
+		so.Metadata[k[len(ecs.UserMetadataPrefix):]] = v
+	}
+	return so
+}
+
+func serveQuery(w http.ResponseWriter, r *http.Request, b *bucket) {
+	query := r.URL.Query()
+	input := &ecs.ListBucketQueryInput{
+		Attributes:          aws.String(query.Get("attributes")),
+		Bucket:              aws.String(b.name),
+		IncludeOlderVersion: aws.Bool(query.Get("include-older-version") == "true"),
+		Marker:              aws.String(query.Get("marker")),
+		Query:               aws.String(query.Get("query")),
+		Sorted:              aws.String(query.Get("sorted")),
+	}
+	if v, err := strconv.ParseInt(query.Get("max-keys"), 10, 64); err == nil {
+		input.MaxKeys = aws.Int64(v)
+	}
+	out, err := b.query(input)
+	if err != nil {
+		writeAPIError(w, err)
+		return
+	}
+
+	res := &bucketQueryResult{
+		Name:       b.name,
+		Marker:     aws.StringValue(input.Marker),
+		NextMarker: aws.StringValue(out.NextMarker),
+		MaxKeys:    aws.Int64Value(out.MaxKeys),
+	}
+	if res.NextMarker == "" {
+		res.NextMarker = lastPageMarker
+	}
+	for _, m := range out.ObjectMatches {
+		match := objectMatch{
+			ObjectName: aws.StringValue(m.ObjectName),
+			ObjectID:   aws.StringValue(m.ObjectId),
+			VersionID:  aws.StringValue(m.VersionId),
+		}
+		for _, md := range m.QueryMetadata {
+			mds := queryMds{Type: aws.StringValue(md.MetadataType)}
+			for _, k := range metadataKeys(md.MetadataMap) {
+				mds.MdMap = append(mds.MdMap, mdEntry{k, aws.StringValue(md.MetadataMap[k])})
+			}
+			match.QueryMds = append(match.QueryMds, mds)
+		}
+		res.ObjectMatches = append(res.ObjectMatches, match)
+	}
+	writeXML(w, res)
+}
+
+func objectKeys(objects map[string]*object) []string {
+	keys := make([]string, 0, len(objects))
+	for k := range objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func metadataKeys(m map[string]*string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,265 @@
+// Package ecstest provides a local stand-in for the ECS S3 API, for tests that
+// must run without a live ECS.
+//
+// The server keeps buckets and objects in memory and implements the S3
+// operations the ecs package relies on together with the ECS extensions:
+// bucket properties and retention, metadata search indexes and queries,
+// stale-read settings, byte-range updates and appends, multipart uploads and
+// object retention. Requests are not authenticated and buckets are addressed
+// in the path.
+//
+//	server := ecstest.NewServer()
+//	defer server.Close()
+//	client := server.Client()
+package ecstest
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+)
+
+// Server is a local ECS S3 endpoint backed by memory.
+type Server struct {
+	*httptest.Server
+
+	// Now returns the current time of the server. Tests may replace it to
+	// control object retention; it defaults to time.Now.
+	Now func() time.Time
+
+	mu sync.Mutex
+	store
+	faults  []*Fault
+	latency time.Duration
+}
+
+// NewServer starts and returns a new Server. The caller should call Close
+// when finished, to shut it down.
+func NewServer() *Server {
+	s := &Server{Now: time.Now, store: store{buckets: map[string]*bucket{}}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// Session returns a session for the server with static credentials,
+// path-style addressing and no retries.
+func (s *Server) Session() *session.Session {
+	return session.Must(session.NewSession(&aws.Config{
+		Credentials:      credentials.NewStaticCredentials("ecstest", "ecstest", ""),
+		Endpoint:         aws.String(s.URL),
+		Region:           aws.String("us-east-1"),
+		S3ForcePathStyle: aws.Bool(true),
+		MaxRetries:       aws.Int(0),
+	}))
+}
+
+// Client returns an ECS client for the server, see Session.
+func (s *Server) Client(opts ...request.Option) *ecs.S3 {
+	return ecs.NewWithOptions(s.Session(), opts...)
+}
+
+// A Fault makes the server delay or fail requests.
+type Fault struct {
+	// Match selects the affected requests; every request if nil.
+	Match func(*http.Request) bool
+	// Count is the number of requests affected; unlimited if 0.
+	Count int
+	// Delay is waited before the request is answered.
+	Delay time.Duration
+	// StatusCode of the error response. If 0, the request is handled normally
+	// after Delay.
+	StatusCode int
+	// Code and Message of the error response.
+	Code    string
+	Message string
+}
+
+// AddFault installs f. Faults apply in the order they were added; the first
+// one matching a request is used.
+func (s *Server) AddFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// SetLatency delays every response by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// fault returns the fault for r, if any, and consumes one of its uses.
+func (s *Server) fault(r *http.Request) (*Fault, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.faults {
+		if f.Match != nil && !f.Match(r) {
+			continue
+		}
+		if f.Count > 0 {
+			if f.Count--; f.Count == 0 {
+				s.faults = append(s.faults[:i:i], s.faults[i+1:]...)
+			}
+		}
+		return f, s.latency
+	}
+	return nil, s.latency
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	f, latency := s.fault(r)
+	if f != nil {
+		latency += f.Delay
+	}
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if f != nil && f.StatusCode != 0 {
+		writeError(w, f.StatusCode, f.Code, f.Message)
+		return
+	}
+
+	bucketName, key := splitPath(r.URL.Path)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch {
+	case bucketName == "":
+		s.serveService(w, r)
+	case key == "":
+		s.serveBucket(w, r, bucketName)
+	default:
+		s.serveObject(w, r, bucketName, key)
+	}
+}
+
+// splitPath splits a path-style request path into bucket and key.
+func splitPath(p string) (string, string) {
+	p = strings.TrimPrefix(p, "/")
+	if i := strings.Index(p, "/"); i >= 0 {
+		return p[:i], p[i+1:]
+	}
+	return p, ""
+}
+
+func (s *Server) serveService(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", "The specified method is not allowed against this resource.")
+		return
+	}
+	if _, ok := r.URL.Query()["searchmetadata"]; ok {
+		writeXML(w, &metadataSearchList{
+			IndexableKeys:      systemIndexableKeys(),
+			OptionalAttributes: optionalAttributes,
+		})
+		return
+	}
+
+	out := &listAllMyBucketsResult{}
+	for _, name := range bucketNames(s.buckets) {
+		out.Buckets = append(out.Buckets, bucketEntry{Name: name, CreationDate: s.buckets[name].created.UTC()})
+	}
+	writeXML(w, out)
+}
+
+// An apiError is an S3 error response.
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func (e *apiError) Error() string {
+	return e.code + ": " + e.message
+}
+
+var (
+	errNoSuchBucket             = &apiError{http.StatusNotFound, "NoSuchBucket", "The specified bucket does not exist"}
+	errNoSuchKey                = &apiError{http.StatusNotFound, "NoSuchKey", "The specified key does not exist."}
+	errBucketAlreadyExists      = &apiError{http.StatusConflict, "BucketAlreadyExists", "The requested bucket name is not available"}
+	errBucketNotEmpty           = &apiError{http.StatusConflict, ecs.ErrCodeBucketNotEmpty, "The bucket you tried to delete is not empty"}
+	errObjectUnderRetention     = &apiError{http.StatusConflict, ecs.ErrCodeObjectUnderRetention, "The object is under retention and cannot be modified"}
+	errMetadataSearchNotEnabled = &apiError{http.StatusBadRequest, ecs.ErrCodeMetadataSearchNotEnabled, "Metadata search is not enabled for this bucket"}
+	errInvalidRetentionPeriod   = &apiError{http.StatusBadRequest, "InvalidArgument", "Invalid retention period"}
+)
+
+func invalidArgument(message string) *apiError {
+	return &apiError{http.StatusBadRequest, "InvalidArgument", message}
+}
+
+func invalidQuery(message string) *apiError {
+	return &apiError{http.StatusBadRequest, ecs.ErrCodeInvalidQuery, message}
+}
+
+func invalidRange(message string) *apiError {
+	return &apiError{http.StatusRequestedRangeNotSatisfiable, ecs.ErrCodeInvalidRange, message}
+}
+
+func retentionReduction(message string) *apiError {
+	return &apiError{http.StatusBadRequest, ecs.ErrCodeRetentionReduction, message}
+}
+
+type errorResponse struct {
+	XMLName   xml.Name `xml:"Error"`
+	Code      string
+	Message   string
+	RequestID string `xml:"RequestId"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(&errorResponse{Code: code, Message: message, RequestID: "ecstest"})
+}
+
+// writeAPIError writes err, if any, as an error response.
+func writeAPIError(w http.ResponseWriter, err error) {
+	switch e := err.(type) {
+	case nil:
+	case *apiError:
+		writeError(w, e.status, e.code, e.message)
+	default:
+		writeError(w, http.StatusInternalServerError, "InternalError", err.Error())
+	}
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,356 @@
+package ecstest_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecsiface"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func putObject(t *testing.T, client ecsiface.ECSAPI, bucket, key, body string, meta map[string]string) {
+	_, err := client.PutObjectExtension(&ecs.PutObjectInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(key),
+		Body:     strings.NewReader(body),
+		Metadata: aws.StringMap(meta),
+	})
+	assert.Nil(t, err)
+}
+
+func getObject(t *testing.T, client ecsiface.ECSAPI, bucket, key string) string {
+	resp, err := client.GetObjectExtension(&s3.GetObjectInput{Bucket: aws.String(bucket), Key: aws.String(key)})
+	if !assert.Nil(t, err) {
+		return ""
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	return string(data)
+}
+
+func TestBucketExtension(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, client ecsiface.ECSAPI, setNow func(func() time.Time)) {
+		_, err := client.CreateBucketExtension(&ecs.CreateBucketInput{
+			Bucket:           aws.String("b"),
+			FileSystemAccess: aws.Bool(true),
+			RetentionPeriod:  aws.Int64(60),
+		})
+		assert.Nil(t, err)
+
+		_, err = client.PutBucketIsStaleAllowed(&ecs.PutBucketIsStaleAllowedInput{
+			Bucket:         aws.String("b"),
+			IsStaleAllowed: aws.Bool(true),
+			TSOReadOnly:    aws.Bool(true),
+		})
+		assert.Nil(t, err)
+
+		stale, err := client.GetBucketIsStaleAllowed(&ecs.GetBucketIsStaleAllowedInput{Bucket: aws.String("b")})
+		if assert.Nil(t, err) {
+			assert.Equal(t, ecs.AccessDuringOutageReadOnly, stale.AccessDuringOutage())
+			assert.False(t, aws.BoolValue(stale.ListDuringOutage))
+		}
+
+		resp, err := client.HeadBucketExtension(&s3.HeadBucketInput{Bucket: aws.String("b")})
+		assert.Nil(t, err)
+		assert.True(t, aws.BoolValue(resp.FileSystemAccess))
+		assert.True(t, aws.BoolValue(resp.IsStaleAllowed))
+		assert.True(t, aws.BoolValue(resp.TSOReadOnly))
+		assert.Equal(t, int64(60), aws.Int64Value(resp.RetentionPeriod))
+
+		_, err = client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+		assert.Equal(t, "BucketAlreadyExists", err.(awserr.Error).Code())
+	})
+}
+
+func TestListBucketQuery(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, client ecsiface.ECSAPI, setNow func(func() time.Time)) {
+		_, err := client.CreateBucketExtension(&ecs.CreateBucketInput{
+			Bucket:         aws.String("b"),
+			MetadataSearch: aws.String("Size,ObjectName,x-amz-meta-color;String,x-amz-meta-weight;Integer"),
+		})
+		assert.Nil(t, err)
+		putObject(t, client, "b", "apple", "aaaa", map[string]string{"color": "red", "weight": "10"})
+		putObject(t, client, "b", "banana", "bb", map[string]string{"color": "yellow", "weight": "9"})
+		putObject(t, client, "b", "cherry", "cccccc", map[string]string{"color": "red", "weight": "2"})
+		putObject(t, client, "b", "plain", "p", nil)
+
+		cases := []struct {
+			query, sorted string
+			expected      []string
+		}{
+			{`x-amz-meta-color=="red"`, "", []string{"apple", "cherry"}},
+			{`x-amz-meta-weight>5`, "x-amz-meta-weight", []string{"banana", "apple"}},
+			{`Size<3 or x-amz-meta-color=='red' and x-amz-meta-weight<5`, "", []string{"banana", "cherry", "plain"}},
+			{`(Size<3 or x-amz-meta-color=="red") AND x-amz-meta-weight<5`, "", []string{"cherry"}},
+			{`ObjectName>="b"`, "Size", []string{"plain", "banana", "cherry"}},
+		}
+		for _, c := range cases {
+			input := &ecs.ListBucketQueryInput{Bucket: aws.String("b"), Query: aws.String(c.query)}
+			if c.sorted != "" {
+				input.Sorted = aws.String(c.sorted)
+			}
+			resp, err := client.ListBucketQuery(input)
+			if !assert.Nil(t, err, c.query) {
+				continue
+			}
+			var names []string
+			for _, m := range resp.ObjectMatches {
+				names = append(names, aws.StringValue(m.ObjectName))
+			}
+			assert.Equal(t, c.expected, names, c.query)
+		}
+
+		resp, err := client.ListBucketQuery(&ecs.ListBucketQueryInput{
+			Bucket:     aws.String("b"),
+			Query:      aws.String("x-amz-meta-color=='yellow'"),
+			Attributes: aws.String("ContentType"),
+		})
+		assert.Nil(t, err)
+		if assert.Len(t, resp.ObjectMatches, 1) {
+			mds := map[string]map[string]*string{}
+			for _, md := range resp.ObjectMatches[0].QueryMetadata {
+				mds[aws.StringValue(md.MetadataType)] = md.MetadataMap
+			}
+			assert.Equal(t, "2", aws.StringValue(mds["SYSMD"]["size"]))
+			assert.Equal(t, "binary/octet-stream", aws.StringValue(mds["SYSMD"]["ctype"]))
+			assert.Equal(t, "9", aws.StringValue(mds["USERMD"]["x-amz-meta-weight"]))
+		}
+
+		var pages [][]string
+		err = client.ListBucketQueryPages(&ecs.ListBucketQueryInput{
+			Bucket:  aws.String("b"),
+			Query:   aws.String("Size>0"),
+			MaxKeys: aws.Int64(3),
+		}, func(page *ecs.ListBucketQueryOutput, last bool) bool {
+			var names []string
+			for _, m := range page.ObjectMatches {
+				names = append(names, aws.StringValue(m.ObjectName))
+			}
+			pages = append(pages, names)
+			return true
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, [][]string{{"apple", "banana", "cherry"}, {"plain"}}, pages)
+
+		for _, query := range []string{"Size>", "CreateTime>0", "(Size>0", `x-amz-meta-weight>"heavy"`} {
+			_, err = client.ListBucketQuery(&ecs.ListBucketQueryInput{Bucket: aws.String("b"), Query: aws.String(query)})
+			assert.True(t, ecs.IsInvalidQuery(err), query)
+		}
+
+		_, err = client.DeleteBucketMetadataSearch(&ecs.DeleteBucketMetadataSearchInput{Bucket: aws.String("b")})
+		assert.Nil(t, err)
+		_, err = client.ListBucketQuery(&ecs.ListBucketQueryInput{Bucket: aws.String("b"), Query: aws.String("Size>0")})
+		assert.True(t, ecs.IsMetadataSearchDisabled(err))
+	})
+}
+
+func TestObjectRange(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, client ecsiface.ECSAPI, setNow func(func() time.Time)) {
+		_, err := client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+		assert.Nil(t, err)
+		putObject(t, client, "b", "k", "hello", nil)
+
+		_, err = client.UpdateObjectRange("b", "k", 1, strings.NewReader("ELL"))
+		assert.Nil(t, err)
+		assert.Equal(t, "hELLo", getObject(t, client, "b", "k"))
+
+		resp, err := client.AppendObject("b", "k", strings.NewReader(" world"))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5), aws.Int64Value(resp.AppendOffset))
+		assert.Equal(t, int64(5), aws.Int64Value(resp.PreviousObjectSize))
+		assert.Equal(t, "hELLo world", getObject(t, client, "b", "k"))
+
+		_, err = client.OverwriteObjectRange("b", "k", 13, strings.NewReader("!"))
+		assert.Nil(t, err)
+		assert.Equal(t, "hELLo world\x00\x00!", getObject(t, client, "b", "k"))
+
+		_, err = client.UpdateObjectRange("b", "k", 20, strings.NewReader("x"))
+		if assert.NotNil(t, err) {
+			assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, err.(awserr.RequestFailure).StatusCode())
+		}
+	})
+}
+
+func TestBucketRetention(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, client ecsiface.ECSAPI, setNow func(func() time.Time)) {
+		_, err := client.CreateBucketExtension(&ecs.CreateBucketInput{
+			Bucket:            aws.String("b"),
+			ComplianceEnabled: aws.Bool(true),
+			RetentionPeriod:   aws.Int64(60),
+		})
+		assert.Nil(t, err)
+
+		err = client.CheckBucketRetentionReduction("b", 30)
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ecs.ErrCodeRetentionReduction, err.(awserr.Error).Code())
+		}
+		_, err = client.PutBucketRetention(&ecs.PutBucketRetentionInput{Bucket: aws.String("b"), RetentionPeriod: aws.Int64(30)})
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ecs.ErrCodeRetentionReduction, err.(awserr.Error).Code())
+		}
+
+		assert.Nil(t, client.CheckBucketRetentionReduction("b", 120))
+		_, err = client.PutBucketRetention(&ecs.PutBucketRetentionInput{Bucket: aws.String("b"), RetentionPeriod: aws.Int64(120)})
+		assert.Nil(t, err)
+		out, err := client.GetBucketRetention(&ecs.GetBucketRetentionInput{Bucket: aws.String("b")})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(120), aws.Int64Value(out.RetentionPeriod))
+	})
+}
+
+func TestObjectRetention(t *testing.T) {
+	forEachImplementation(t, func(t *testing.T, client ecsiface.ECSAPI, setNow func(func() time.Time)) {
+		now := time.Now()
+		setNow(func() time.Time { return now })
+
+		_, err := client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+		assert.Nil(t, err)
+		_, err = client.PutObjectExtension(&ecs.PutObjectInput{
+			Bucket:          aws.String("b"),
+			Key:             aws.String("k"),
+			Body:            strings.NewReader("data"),
+			RetentionPeriod: aws.Int64(60),
+		})
+		assert.Nil(t, err)
+
+		head, err := client.HeadObjectExtension(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+		assert.Nil(t, err)
+		assert.Equal(t, int64(60), aws.Int64Value(head.RetentionPeriod))
+
+		_, err = client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+		assert.IsType(t, &ecs.RetentionError{}, err)
+		assert.True(t, ecs.IsRetentionViolation(err))
+
+		_, err = client.PutObjectRetention(&ecs.PutObjectRetentionInput{
+			Bucket:          aws.String("b"),
+			Key:             aws.String("k"),
+			RetentionPeriod: aws.Int64(10),
+		})
+		if assert.NotNil(t, err) {
+			assert.Equal(t, ecs.ErrCodeRetentionReduction, err.(awserr.Error).Code())
+		}
+
+		now = now.Add(time.Minute)
+		_, err = client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+		assert.Nil(t, err)
+	})
+}
+
+func TestMultipartUpload(t *testing.T) {
+	server := ecstest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+
+	created, err := client.CreateMultipartUpload(&s3.CreateMultipartUploadInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if !assert.Nil(t, err) {
+		return
+	}
+	var parts []*s3.CompletedPart
+	for i, body := range []string{"hello ", "world"} {
+		out, err := client.UploadPart(&s3.UploadPartInput{
+			Bucket:     aws.String("b"),
+			Key:        aws.String("k"),
+			UploadId:   created.UploadId,
+			PartNumber: aws.Int64(int64(i + 1)),
+			Body:       strings.NewReader(body),
+		})
+		assert.Nil(t, err)
+		parts = append(parts, &s3.CompletedPart{ETag: out.ETag, PartNumber: aws.Int64(int64(i + 1))})
+	}
+
+	complete := &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String("b"),
+		Key:             aws.String("k"),
+		UploadId:        created.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: []*s3.CompletedPart{{ETag: aws.String(`"bad"`), PartNumber: aws.Int64(1)}}},
+	}
+	_, err = client.CompleteMultipartUpload(complete)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "InvalidPart", err.(awserr.Error).Code())
+	}
+	complete.MultipartUpload.Parts = parts
+	_, err = client.CompleteMultipartUpload(complete)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", getObject(t, client, "b", "k"))
+
+	_, err = client.AbortMultipartUpload(&s3.AbortMultipartUploadInput{Bucket: aws.String("b"), Key: aws.String("k"), UploadId: created.UploadId})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "NoSuchUpload", err.(awserr.Error).Code())
+	}
+}
+
+func TestFault(t *testing.T) {
+	server := ecstest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	server.AddFault(ecstest.Fault{
+		Match:      func(r *http.Request) bool { return r.Method == "PUT" },
+		Count:      1,
+		StatusCode: http.StatusServiceUnavailable,
+		Code:       "SlowDown",
+		Message:    "Please reduce your request rate.",
+	})
+	_, err := client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "SlowDown", err.(awserr.Error).Code())
+		assert.Equal(t, http.StatusServiceUnavailable, err.(awserr.RequestFailure).StatusCode())
+	}
+	_, err = client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+
+	server.AddFault(ecstest.Fault{StatusCode: http.StatusInternalServerError, Code: "InternalError"})
+	_, err = client.HeadBucketExtension(&s3.HeadBucketInput{Bucket: aws.String("b")})
+	assert.NotNil(t, err)
+	server.ClearFaults()
+	_, err = client.HeadBucketExtension(&s3.HeadBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+}
+
+func TestLatency(t *testing.T) {
+	server := ecstest.NewServer()
+	defer server.Close()
+	client := server.Client()
+
+	server.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.CreateBucketExtensionWithContext(ctx, &ecs.CreateBucketInput{Bucket: aws.String("b")})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "RequestCanceled", err.(awserr.Error).Code())
+	}
+
+	server.SetLatency(0)
+	server.AddFault(ecstest.Fault{Count: 1, Delay: 20 * time.Millisecond})
+	start := time.Now()
+	_, err = client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	assert.True(t, time.Since(start) >= 20*time.Millisecond)
+}
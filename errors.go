@@ -0,0 +1,167 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// ErrCodeMetadataSearchNotEnabled is the error code ECS returns for a
+	// metadata search of a bucket created without indexed keys.
+	ErrCodeMetadataSearchNotEnabled = "MetadataSearchNotEnabled"
+
+	// ErrCodeInvalidQuery is the error code ECS returns for a metadata search
+	// query that is malformed or uses keys that are not indexed.
+	ErrCodeInvalidQuery = "InvalidQuery"
+
+	// ErrCodeBucketNotEmpty is the error code ECS returns when deleting a
+	// bucket that still holds objects, such as objects of a compliance-enabled
+	// bucket whose retention has not expired.
+	ErrCodeBucketNotEmpty = "BucketNotEmpty"
+
+	// ErrCodeStaleReadNotAllowed is the error code ECS returns when a read
+	// cannot be served during a site outage because the bucket does not allow
+	// stale reads.
+	ErrCodeStaleReadNotAllowed = "StaleReadNotAllowed"
+
+	// ErrCodeInvalidRange is the error code ECS returns for a byte range that
+	// cannot be read or written, such as an append at an offset other than
+	// the end of the object.
+	ErrCodeInvalidRange = "InvalidRange"
+)
+
+var errorHandler = request.NamedHandler{
+	Name: "ecs.ErrorHandler",
+	Fn:   classifyError,
+}
+
+// classifyError gives request failures ECS reports without one of the ECS
+// error codes the code they stand for, and turns failures caused by object
+// retention into a RetentionError.
+func classifyError(r *request.Request) {
+	failure, ok := r.Error.(awserr.RequestFailure)
+	if !ok {
+		return
+	}
+	if code := errorCode(failure); code != failure.Code() {
+		failure = &codedFailure{failure, code}
+	}
+	if failure.Code() == ErrCodeObjectUnderRetention {
+		r.Error = &RetentionError{failure}
+		return
+	}
+	r.Error = failure
+}
+
+// retentionCodes are the generic codes some ECS versions report retention
+// failures with. Other codes, such as AccessDenied, keep their meaning even
+// when the message mentions retention.
+var retentionCodes = map[string]bool{
+	"Conflict":  true,
+	"Forbidden": true,
+}
+
+// errorCode returns the ECS error code of failure. Some ECS versions report
+// these failures with generic codes, so they are recognized by status and
+// message.
+func errorCode(failure awserr.RequestFailure) string {
+	message := strings.ToLower(failure.Message())
+	switch failure.StatusCode() {
+	case http.StatusForbidden, http.StatusConflict:
+		if retentionCodes[failure.Code()] && strings.Contains(message, "retention") {
+			return ErrCodeObjectUnderRetention
+		}
+	case http.StatusBadRequest:
+		if strings.Contains(message, "metadata search") && strings.Contains(message, "not enabled") {
+			return ErrCodeMetadataSearchNotEnabled
+		}
+	case http.StatusServiceUnavailable:
+		if strings.Contains(message, "stale") {
+			return ErrCodeStaleReadNotAllowed
+		}
+	}
+	return failure.Code()
+}
+
+// A codedFailure is a request failure with the error code it stands for.
+type codedFailure struct {
+	awserr.RequestFailure
+	code string
+}
+
+func (e *codedFailure) Code() string {
+	return e.code
+}
+
+func (e *codedFailure) Error() string {
+	extra := fmt.Sprintf("status code: %d, request id: %s", e.StatusCode(), e.RequestID())
+	return awserr.SprintError(e.code, e.Message(), extra, e.OrigErr())
+}
+
+func (e *codedFailure) String() string {
+	return e.Error()
+}
+
+// IsRetentionViolation reports whether err is a failure to delete or modify an
+// object whose retention has not expired.
+func IsRetentionViolation(err error) bool {
+	if _, ok := err.(*RetentionError); ok {
+		return true
+	}
+	return hasErrorCode(err, ErrCodeObjectUnderRetention)
+}
+
+// IsMetadataSearchDisabled reports whether err is a failure to search a bucket
+// without metadata search.
+func IsMetadataSearchDisabled(err error) bool {
+	return hasErrorCode(err, ErrCodeMetadataSearchNotEnabled)
+}
+
+// IsInvalidQuery reports whether err is a failure caused by an invalid
+// metadata search query.
+func IsInvalidQuery(err error) bool {
+	return hasErrorCode(err, ErrCodeInvalidQuery)
+}
+
+// IsStaleReadNotAllowed reports whether err is a failure to read from a bucket
+// that does not allow stale reads during a site outage.
+func IsStaleReadNotAllowed(err error) bool {
+	return hasErrorCode(err, ErrCodeStaleReadNotAllowed)
+}
+
+// IsBucketNotEmpty reports whether err is a failure to delete a bucket that
+// still holds objects, such as objects under retention in a
+// compliance-enabled bucket.
+func IsBucketNotEmpty(err error) bool {
+	return hasErrorCode(err, ErrCodeBucketNotEmpty)
+}
+
+// IsInvalidRange reports whether err is a failure to read or write a byte
+// range, such as an append at an offset other than the end of the object.
+func IsInvalidRange(err error) bool {
+	return hasErrorCode(err, ErrCodeInvalidRange)
+}
+
+func hasErrorCode(err error, code string) bool {
+	e, ok := err.(awserr.Error)
+	return ok && e.Code() == code
+}
@@ -0,0 +1,104 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// errorServer answers every request with an error of status and body.
+func errorServer(status int, body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+}
+
+func TestErrorCodes(t *testing.T) {
+	for _, test := range []struct {
+		status int
+		body   string
+		code   string
+	}{
+		{http.StatusBadRequest, "<Error><Code>MetadataSearchNotEnabled</Code><Message>Metadata search is not enabled for this bucket</Message></Error>", ecs.ErrCodeMetadataSearchNotEnabled},
+		{http.StatusBadRequest, "<Error><Code>InvalidArgument</Code><Message>Metadata search not enabled for bucket b</Message></Error>", ecs.ErrCodeMetadataSearchNotEnabled},
+		{http.StatusBadRequest, "<Error><Code>InvalidQuery</Code><Message>Invalid query</Message></Error>", ecs.ErrCodeInvalidQuery},
+		{http.StatusServiceUnavailable, "<Error><Code>ServiceUnavailable</Code><Message>Stale reads are not allowed for this bucket</Message></Error>", ecs.ErrCodeStaleReadNotAllowed},
+		{http.StatusConflict, "<Error><Code>BucketNotEmpty</Code><Message>Objects under retention</Message></Error>", ecs.ErrCodeBucketNotEmpty},
+		{http.StatusConflict, "<Error><Code>Conflict</Code><Message>Object is under retention</Message></Error>", ecs.ErrCodeObjectUnderRetention},
+		{http.StatusForbidden, "<Error><Code>Forbidden</Code><Message>Object is under retention</Message></Error>", ecs.ErrCodeObjectUnderRetention},
+		{http.StatusForbidden, "<Error><Code>AccessDenied</Code><Message>Access to objects under retention is denied</Message></Error>", "AccessDenied"},
+		{http.StatusForbidden, "<Error><Code>InvalidArgument</Code><Message>Invalid retention period</Message></Error>", "InvalidArgument"},
+		{http.StatusForbidden, "<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>", "AccessDenied"},
+	} {
+		server := errorServer(test.status, test.body)
+		client := newLocalClient(server.URL)
+		_, err := client.ListBucketQuery(&ecs.ListBucketQueryInput{Bucket: aws.String("b"), Query: aws.String("Size>0")})
+		server.Close()
+
+		if assert.NotNil(t, err, test.body) {
+			failure := err.(awserr.RequestFailure)
+			assert.Equal(t, test.code, failure.Code(), test.body)
+			assert.Equal(t, test.status, failure.StatusCode(), test.body)
+			assert.Contains(t, failure.Error(), test.code, test.body)
+		}
+	}
+}
+
+func TestErrorClassification(t *testing.T) {
+	server := errorServer(http.StatusBadRequest, "<Error><Code>MetadataSearchNotEnabled</Code><Message>not enabled</Message></Error>")
+	defer server.Close()
+	client := newLocalClient(server.URL)
+	_, err := client.ListBucketQuery(&ecs.ListBucketQueryInput{Bucket: aws.String("b"), Query: aws.String("Size>0")})
+	assert.True(t, ecs.IsMetadataSearchDisabled(err))
+	assert.False(t, ecs.IsInvalidQuery(err))
+	assert.False(t, ecs.IsRetentionViolation(err))
+
+	retention := errorServer(http.StatusConflict, "<Error><Code>ObjectUnderRetention</Code><Message>The object is under retention</Message></Error>")
+	defer retention.Close()
+	client = newLocalClient(retention.URL)
+	_, err = client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.IsType(t, &ecs.RetentionError{}, err)
+	assert.True(t, ecs.IsRetentionViolation(err))
+	assert.False(t, ecs.IsMetadataSearchDisabled(err))
+
+	denied := errorServer(http.StatusForbidden, "<Error><Code>AccessDenied</Code><Message>Not allowed to change the retention of the object</Message></Error>")
+	defer denied.Close()
+	client = newLocalClient(denied.URL)
+	_, err = client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.False(t, ecs.IsRetentionViolation(err))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "AccessDenied", err.(awserr.Error).Code())
+	}
+
+	assert.True(t, ecs.IsInvalidQuery(awserr.New(ecs.ErrCodeInvalidQuery, "invalid", nil)))
+	assert.True(t, ecs.IsStaleReadNotAllowed(awserr.New(ecs.ErrCodeStaleReadNotAllowed, "stale", nil)))
+	assert.True(t, ecs.IsBucketNotEmpty(awserr.New(ecs.ErrCodeBucketNotEmpty, "not empty", nil)))
+	assert.False(t, ecs.IsBucketNotEmpty(awserr.New(ecs.ErrCodeInvalidRange, "invalid range", nil)))
+	assert.True(t, ecs.IsInvalidRange(awserr.New(ecs.ErrCodeInvalidRange, "invalid range", nil)))
+	assert.False(t, ecs.IsInvalidRange(errors.New(ecs.ErrCodeInvalidRange)))
+	assert.False(t, ecs.IsRetentionViolation(errors.New(ecs.ErrCodeObjectUnderRetention)))
+	assert.False(t, ecs.IsRetentionViolation(nil))
+}
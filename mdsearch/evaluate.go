@@ -0,0 +1,339 @@
+package mdsearch
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Object describes an object for Match and Evaluate.
+type Object struct {
+	Name string
+	// ObjectId and VersionId are reported in the matches as they are.
+	ObjectId  string
+	VersionId string
+	// OlderVersion marks versions other than the latest one of an object.
+	// They only match queries that include older versions.
+	OlderVersion bool
+
+	Size         int64
+	CreateTime   time.Time
+	LastModified time.Time
+	Owner        string
+
+	// Optional attributes, reported when a query requests them. Zero times
+	// are not reported.
+	ContentType string
+	Expiration  time.Time
+	Expires     time.Time
+	// Retention is the retention period in seconds.
+	Retention int64
+
+	// Metadata is the user metadata, keyed by name without the x-amz-meta-
+	// prefix as in s3.PutObjectInput. Names are not case sensitive.
+	Metadata map[string]string
+}
+
+// value returns the value of the system or user key name as text.
+func (o *Object) value(name string) (string, bool) {
+	if ecs.IsUserMetadataKey(name) {
+		name = name[len(ecs.UserMetadataPrefix):]
+		for k, v := range o.Metadata {
+			if strings.EqualFold(k, name) {
+				return v, true
+			}
+		}
+		return "", false
+	}
+	switch strings.ToLower(name) {
+	case "objectname":
+		return o.Name, true
+	case "owner":
+		return o.Owner, true
+	case "size":
+		return strconv.FormatInt(o.Size, 10), true
+	case "createtime":
+		return epochMillis(o.CreateTime), true
+	case "lastmodified":
+		return epochMillis(o.LastModified), true
+	}
+	return "", false
+}
+
+// typedValue returns the value of the key name parsed as datatype. Objects
+// whose value is not valid for the datatype do not have the key indexed.
+func (o *Object) typedValue(name, datatype string) (interface{}, bool) {
+	v, ok := o.value(name)
+	if !ok {
+		return nil, false
+	}
+	t, err := ecs.ParseMetadataValue(datatype, v)
+	return t, err == nil
+}
+
+func epochMillis(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+}
+
+// datatype returns the datatype of the key name. Without a schema, user keys
+// are String keys.
+func datatype(s *Schema, name string) string {
+	if s != nil {
+		d, _ := s.Datatype(name)
+		return d
+	}
+	if d, ok := ecs.SystemMetadataSearchKeyDatatype(name); ok {
+		return d
+	}
+	return ecs.DatatypeString
+}
+
+// compare compares two values returned by ecs.ParseMetadataValue for the same
+// datatype.
+func compare(a, b interface{}) int {
+	switch va := a.(type) {
+	case string:
+		return strings.Compare(va, b.(string))
+	case int64:
+		return compareFloats(float64(va), float64(b.(int64)))
+	case float64:
+		return compareFloats(va, b.(float64))
+	case time.Time:
+		vb := b.(time.Time)
+		switch {
+		case va.Before(vb):
+			return -1
+		case va.After(vb):
+			return 1
+		}
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func (c *condition) eval(o *Object, s *Schema) (bool, error) {
+	d := datatype(s, c.key)
+	if s == nil && ecs.IsUserMetadataKey(c.key) {
+		// Without a schema the literal tells how the key is indexed, so that
+		// Gt(5) compares numbers rather than text.
+		d = c.value.datatype
+	}
+	lit, err := ecs.ParseMetadataValue(d, c.value.raw)
+	if err != nil {
+		return false, fmt.Errorf("mdsearch: invalid %s literal %s for key %q", d, c.value.text, c.key)
+	}
+	v, ok := o.typedValue(c.key, d)
+	if !ok {
+		return false, nil
+	}
+	n := compare(v, lit)
+	switch c.op {
+	case opEq:
+		return n == 0, nil
+	case opNe:
+		return n != 0, nil
+	case opLt:
+		return n < 0, nil
+	case opLe:
+		return n <= 0, nil
+	case opGt:
+		return n > 0, nil
+	case opGe:
+		return n >= 0, nil
+	}
+	return false, fmt.Errorf("mdsearch: unknown operator %q", c.op)
+}
+
+func (j *junction) eval(o *Object, s *Schema) (bool, error) {
+	and := j.op == "and"
+	for _, e := range j.exprs {
+		ok, err := e.eval(o, s)
+		if err != nil {
+			return false, err
+		}
+		if ok != and {
+			return ok, nil
+		}
+	}
+	return and, nil
+}
+
+func (g *group) eval(o *Object, s *Schema) (bool, error) {
+	return g.expr.eval(o, s)
+}
+
+// Match reports whether o matches e. e is validated against s as with
+// Validate; without a schema user keys are compared as the datatype of the
+// literal they are compared with.
+func Match(e Expr, s *Schema, o *Object) (bool, error) {
+	if err := Validate(e, s); err != nil {
+		return false, err
+	}
+	return e.eval(o, s)
+}
+
+// Evaluate runs the ListBucketQuery described by input against objects, the
+// objects of the bucket, and returns the page of results ECS would. The keys
+// of the query and of Sorted must be indexed in s; without a schema user keys
+// are compared as the datatype of their literals, sorted as String keys and
+// every user key is reported.
+//
+// Matches are sorted by object name unless input sets Sorted. The returned
+// NextMarker is the offset of the next page, nil on the last page.
+func Evaluate(input *ecs.ListBucketQueryInput, s *Schema, objects []*Object) (*ecs.ListBucketQueryOutput, error) {
+	e, err := Parse(aws.StringValue(input.Query))
+	if err != nil {
+		return nil, err
+	}
+	if err := Validate(e, s); err != nil {
+		return nil, err
+	}
+	sorted := aws.StringValue(input.Sorted)
+	if sorted != "" {
+		if err := ecs.ValidateMetadataSearchKeyName(sorted); err != nil {
+			return nil, fmt.Errorf("mdsearch: %v", err)
+		}
+		if _, ok := s.Datatype(sorted); s != nil && !ok {
+			return nil, fmt.Errorf("mdsearch: sort key %q is not indexed", sorted)
+		}
+	}
+
+	var matches []*Object
+	for _, o := range objects {
+		if o.OlderVersion && !aws.BoolValue(input.IncludeOlderVersion) {
+			continue
+		}
+		ok, err := e.eval(o, s)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			matches = append(matches, o)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if sorted != "" {
+			if n := compareKey(matches[i], matches[j], sorted, datatype(s, sorted)); n != 0 {
+				return n < 0
+			}
+		}
+		return matches[i].Name < matches[j].Name
+	})
+
+	maxKeys := int(aws.Int64Value(input.MaxKeys))
+	if maxKeys <= 0 {
+		maxKeys = 1000
+	}
+	out := &ecs.ListBucketQueryOutput{
+		MaxKeys: aws.Int64(int64(maxKeys)),
+		Name:    input.Bucket,
+	}
+	offset, _ := strconv.Atoi(aws.StringValue(input.Marker))
+	if offset < 0 || offset > len(matches) {
+		offset = len(matches)
+	}
+	end := offset + maxKeys
+	if end < len(matches) {
+		out.NextMarker = aws.String(strconv.Itoa(end))
+	} else {
+		end = len(matches)
+	}
+
+	attributes := strings.Split(aws.StringValue(input.Attributes), ",")
+	for _, o := range matches[offset:end] {
+		out.ObjectMatches = append(out.ObjectMatches, objectMatch(o, s, attributes))
+	}
+	return out, nil
+}
+
+// compareKey compares a and b by the key name. Objects without the key sort
+// last.
+func compareKey(a, b *Object, name, d string) int {
+	va, okA := a.typedValue(name, d)
+	vb, okB := b.typedValue(name, d)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return 1
+	case !okB:
+		return -1
+	}
+	return compare(va, vb)
+}
+
+func objectMatch(o *Object, s *Schema, attributes []string) *ecs.EcsObjectMatch {
+	sys := map[string]*string{
+		"createtime": aws.String(epochMillis(o.CreateTime)),
+		"mtime":      aws.String(epochMillis(o.LastModified)),
+		"owner":      aws.String(o.Owner),
+		"size":       aws.String(strconv.FormatInt(o.Size, 10)),
+	}
+	for _, a := range attributes {
+		switch strings.TrimSpace(a) {
+		case "ContentType":
+			sys["ctype"] = aws.String(o.ContentType)
+		case "Expiration":
+			if !o.Expiration.IsZero() {
+				sys["expiration"] = aws.String(epochMillis(o.Expiration))
+			}
+		case "Expires":
+			if !o.Expires.IsZero() {
+				sys["expires"] = aws.String(epochMillis(o.Expires))
+			}
+		case "Retention":
+			sys["retention"] = aws.String(strconv.FormatInt(o.Retention, 10))
+		}
+	}
+	m := &ecs.EcsObjectMatch{
+		ObjectName:    aws.String(o.Name),
+		QueryMetadata: []*ecs.EcsQueryMetadata{{MetadataType: aws.String(ecs.MetadataTypeSystem), MetadataMap: sys}},
+	}
+	if o.ObjectId != "" {
+		m.ObjectId = aws.String(o.ObjectId)
+	}
+	if o.VersionId != "" {
+		m.VersionId = aws.String(o.VersionId)
+	}
+
+	user := map[string]*string{}
+	for k, v := range o.Metadata {
+		name := ecs.UserMetadataPrefix + strings.ToLower(k)
+		if _, ok := s.Datatype(name); s == nil || ok {
+			user[name] = aws.String(v)
+		}
+	}
+	if len(user) > 0 {
+		m.QueryMetadata = append(m.QueryMetadata, &ecs.EcsQueryMetadata{MetadataType: aws.String(ecs.MetadataTypeUser), MetadataMap: user})
+	}
+	return m
+}
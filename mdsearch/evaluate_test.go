@@ -0,0 +1,193 @@
+package mdsearch_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/mdsearch"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	modified = time.Date(2017, 6, 1, 10, 30, 0, 0, time.UTC)
+	objects  = []*mdsearch.Object{
+		{Name: "a", Size: 1, LastModified: modified, Metadata: map[string]string{"str": "red", "int": "10"}},
+		{Name: "b", Size: 2, LastModified: modified.Add(time.Hour), Metadata: map[string]string{"STR": "blue", "INT": "9"}},
+		{Name: "c", Size: 3, LastModified: modified.Add(2 * time.Hour), Metadata: map[string]string{"dec": "2.5"}},
+		{Name: "d", Size: 4, LastModified: modified.Add(-time.Hour), OlderVersion: true, Metadata: map[string]string{"int": "1"}},
+	}
+)
+
+func matchNames(out *ecs.ListBucketQueryOutput) []string {
+	var names []string
+	for _, m := range out.ObjectMatches {
+		names = append(names, aws.StringValue(m.ObjectName))
+	}
+	return names
+}
+
+func TestMatch(t *testing.T) {
+	ok, err := mdsearch.Match(mdsearch.Key("x-amz-meta-INT").Gt(9), schema, objects[0])
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// integers compare as numbers, not as text
+	ok, err = mdsearch.Match(mdsearch.Key("x-amz-meta-INT").Gt(9), schema, objects[1])
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	// objects without the key do not match, even for !=
+	ok, err = mdsearch.Match(mdsearch.Key("x-amz-meta-STR").Ne("red"), schema, objects[2])
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	ok, err = mdsearch.Match(mdsearch.Key("LastModified").Lt(modified.Add(time.Minute)), schema, objects[0])
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	// system keys are not case sensitive
+	ok, err = mdsearch.Match(mdsearch.Key("size").Eq(1), schema, objects[0])
+	assert.Nil(t, err)
+	assert.True(t, ok)
+
+	_, err = mdsearch.Match(mdsearch.Key("x-amz-meta-OTHER").Eq("red"), schema, objects[0])
+	assert.NotNil(t, err)
+
+	// without a schema user keys take the datatype of the literal
+	ok, err = mdsearch.Match(mdsearch.Key("x-amz-meta-INT").Gt(5), nil, objects[0])
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	ok, err = mdsearch.Match(mdsearch.Key("x-amz-meta-STR").Gt(5), nil, objects[0])
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	ok, err = mdsearch.Match(mdsearch.Key("x-amz-meta-INT").Gt("5"), nil, objects[0])
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func TestEvaluate(t *testing.T) {
+	for query, names := range map[string][]string{
+		`Size > 1`: {"b", "c"},
+		`x-amz-meta-str == "red" or x-amz-meta-dec > 2`:                   {"a", "c"},
+		`Size < 3 and (x-amz-meta-STR == "blue" or x-amz-meta-INT >= 10)`: {"a", "b"},
+		`LastModified > 2017-06-01T10:30:00Z`:                             {"b", "c"},
+		`x-amz-meta-INT < 0`:                                              nil,
+	} {
+		out, err := mdsearch.Evaluate(&ecs.ListBucketQueryInput{
+			Bucket: aws.String("bucket"),
+			Query:  aws.String(query),
+		}, schema, objects)
+		if assert.Nil(t, err, query) {
+			assert.Equal(t, names, matchNames(out), query)
+			assert.Equal(t, "bucket", aws.StringValue(out.Name))
+			assert.Nil(t, out.NextMarker)
+		}
+	}
+
+	for _, query := range []string{
+		`Size > `,
+		`Owner == "me"`,
+		`x-amz-meta-INT == "10"`,
+	} {
+		_, err := mdsearch.Evaluate(&ecs.ListBucketQueryInput{Query: aws.String(query)}, schema, objects)
+		assert.NotNil(t, err, query)
+	}
+}
+
+func TestEvaluateSorted(t *testing.T) {
+	out, err := mdsearch.Evaluate(&ecs.ListBucketQueryInput{
+		Query:               aws.String(`Size > 0`),
+		Sorted:              aws.String("x-amz-meta-INT"),
+		IncludeOlderVersion: aws.Bool(true),
+	}, schema, objects)
+	if assert.Nil(t, err) {
+		// objects without the sort key come last
+		assert.Equal(t, []string{"d", "b", "a", "c"}, matchNames(out))
+	}
+
+	_, err = mdsearch.Evaluate(&ecs.ListBucketQueryInput{
+		Query:  aws.String(`Size > 0`),
+		Sorted: aws.String("x-amz-meta-OTHER"),
+	}, schema, objects)
+	assert.NotNil(t, err)
+}
+
+func TestEvaluatePages(t *testing.T) {
+	input := &ecs.ListBucketQueryInput{
+		Query:               aws.String(`Size > 0`),
+		MaxKeys:             aws.Int64(3),
+		IncludeOlderVersion: aws.Bool(true),
+	}
+	out, err := mdsearch.Evaluate(input, schema, objects)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"a", "b", "c"}, matchNames(out))
+		assert.Equal(t, int64(3), aws.Int64Value(out.MaxKeys))
+		assert.NotNil(t, out.NextMarker)
+	}
+
+	input.Marker = out.NextMarker
+	out, err = mdsearch.Evaluate(input, schema, objects)
+	if assert.Nil(t, err) {
+		assert.Equal(t, []string{"d"}, matchNames(out))
+		assert.Nil(t, out.NextMarker)
+	}
+}
+
+func TestEvaluateMetadata(t *testing.T) {
+	o := &mdsearch.Object{
+		Name:         "k",
+		ObjectId:     "id",
+		VersionId:    "0",
+		Size:         5,
+		CreateTime:   modified,
+		LastModified: modified,
+		Owner:        "user",
+		ContentType:  "text/plain",
+		Retention:    60,
+		Metadata:     map[string]string{"Str": "red", "Unindexed": "x"},
+	}
+	out, err := mdsearch.Evaluate(&ecs.ListBucketQueryInput{
+		Query:      aws.String(`Size == 5`),
+		Attributes: aws.String("ContentType,Retention"),
+	}, schema, []*mdsearch.Object{o})
+	if assert.Nil(t, err) && assert.Len(t, out.ObjectMatches, 1) {
+		m := out.ObjectMatches[0]
+		assert.Equal(t, "id", aws.StringValue(m.ObjectId))
+		assert.Equal(t, "0", aws.StringValue(m.VersionId))
+		if assert.Len(t, m.QueryMetadata, 2) {
+			assert.Equal(t, map[string]string{
+				"createtime": "1496313000000",
+				"mtime":      "1496313000000",
+				"owner":      "user",
+				"size":       "5",
+				"ctype":      "text/plain",
+				"retention":  "60",
+			}, aws.StringValueMap(m.QueryMetadata[0].MetadataMap))
+			assert.Equal(t, ecs.MetadataTypeUser, aws.StringValue(m.QueryMetadata[1].MetadataType))
+			assert.Equal(t, map[string]string{"x-amz-meta-str": "red"}, aws.StringValueMap(m.QueryMetadata[1].MetadataMap))
+		}
+	}
+
+	// without a schema every user key is reported
+	out, err = mdsearch.Evaluate(&ecs.ListBucketQueryInput{Query: aws.String(`x-amz-meta-unindexed == "x"`)}, nil, []*mdsearch.Object{o})
+	if assert.Nil(t, err) && assert.Len(t, out.ObjectMatches, 1) {
+		assert.Len(t, out.ObjectMatches[0].QueryMetadata[1].MetadataMap, 2)
+	}
+}
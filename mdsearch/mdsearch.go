@@ -0,0 +1,325 @@
+// Package mdsearch provides a builder, a parser and a local evaluator for ECS
+// metadata search queries.
+package mdsearch
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	opEq = "=="
+	opNe = "!="
+	opLt = "<"
+	opLe = "<="
+	opGt = ">"
+	opGe = ">="
+)
+
+// Expr is a node of a metadata search query.
+type Expr interface {
+	// String renders the expression in ECS query syntax.
+	String() string
+
+	validate(s *Schema) error
+	eval(o *Object, s *Schema) (bool, error)
+}
+
+// Value is a typed literal used as the argument of a condition.
+type Value struct {
+	datatype string
+	text     string
+	// raw is the unquoted text of the literal.
+	raw string
+	err error
+}
+
+// StringValue returns a String literal.
+func StringValue(v string) Value {
+	return Value{datatype: ecs.DatatypeString, text: quote(v), raw: v}
+}
+
+// IntegerValue returns an Integer literal.
+func IntegerValue(v int64) Value {
+	return literal(ecs.DatatypeInteger, strconv.FormatInt(v, 10))
+}
+
+// DecimalValue returns a Decimal literal.
+func DecimalValue(v float64) Value {
+	return literal(ecs.DatatypeDecimal, strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// DatetimeValue returns a Datetime literal. The time is converted to UTC.
+func DatetimeValue(v time.Time) Value {
+	return literal(ecs.DatatypeDatetime, v.UTC().Format(ecs.DatetimeFormat))
+}
+
+// literal returns an unquoted literal of datatype.
+func literal(datatype, text string) Value {
+	return Value{datatype: datatype, text: text, raw: text}
+}
+
+// Datatype returns the datatype of the literal.
+func (v Value) Datatype() string {
+	return v.datatype
+}
+
+// String returns the literal as it appears in a query.
+func (v Value) String() string {
+	return v.text
+}
+
+// valueOf converts a Go value into a query literal.
+func valueOf(v interface{}) Value {
+	switch t := v.(type) {
+	case Value:
+		return t
+	case string:
+		return StringValue(t)
+	case *string:
+		return StringValue(aws.StringValue(t))
+	case int:
+		return IntegerValue(int64(t))
+	case int32:
+		return IntegerValue(int64(t))
+	case int64:
+		return IntegerValue(t)
+	case *int64:
+		return IntegerValue(aws.Int64Value(t))
+	case uint32:
+		return IntegerValue(int64(t))
+	case float32:
+		return DecimalValue(float64(t))
+	case float64:
+		return DecimalValue(t)
+	case *float64:
+		return DecimalValue(aws.Float64Value(t))
+	case time.Time:
+		return DatetimeValue(t)
+	case *time.Time:
+		return DatetimeValue(aws.TimeValue(t))
+	}
+	return Value{err: fmt.Errorf("mdsearch: unsupported literal type %T", v)}
+}
+
+// quote encloses s in double quotes, escaping quotes and backslashes.
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// Selector names an indexed key on the left hand side of a condition.
+type Selector struct {
+	name string
+}
+
+// Key returns a Selector for the system or user metadata key name.
+func Key(name string) Selector {
+	return Selector{name: name}
+}
+
+// Name returns the key name of the selector.
+func (k Selector) Name() string {
+	return k.name
+}
+
+// Eq matches objects whose key equals v.
+func (k Selector) Eq(v interface{}) Expr {
+	return k.compare(opEq, v)
+}
+
+// Ne matches objects whose key does not equal v.
+func (k Selector) Ne(v interface{}) Expr {
+	return k.compare(opNe, v)
+}
+
+// Lt matches objects whose key is less than v.
+func (k Selector) Lt(v interface{}) Expr {
+	return k.compare(opLt, v)
+}
+
+// Le matches objects whose key is less than or equal to v.
+func (k Selector) Le(v interface{}) Expr {
+	return k.compare(opLe, v)
+}
+
+// Gt matches objects whose key is greater than v.
+func (k Selector) Gt(v interface{}) Expr {
+	return k.compare(opGt, v)
+}
+
+// Ge matches objects whose key is greater than or equal to v.
+func (k Selector) Ge(v interface{}) Expr {
+	return k.compare(opGe, v)
+}
+
+func (k Selector) compare(op string, v interface{}) Expr {
+	return &condition{key: k.name, op: op, value: valueOf(v)}
+}
+
+type condition struct {
+	key   string
+	op    string
+	value Value
+}
+
+func (c *condition) String() string {
+	return c.key + " " + c.op + " " + c.value.text
+}
+
+func (c *condition) validate(s *Schema) error {
+	if c.value.err != nil {
+		return c.value.err
+	}
+	if err := ecs.ValidateMetadataSearchKeyName(c.key); err != nil {
+		return fmt.Errorf("mdsearch: %v", err)
+	}
+
+	datatype, ok := ecs.SystemMetadataSearchKeyDatatype(c.key)
+	if s != nil {
+		if datatype, ok = s.Datatype(c.key); !ok {
+			return fmt.Errorf("mdsearch: key %q is not indexed", c.key)
+		}
+	}
+	if ok && !compatible(datatype, c.value.datatype) {
+		return fmt.Errorf("mdsearch: key %q is %s, cannot compare with %s literal %s",
+			c.key, datatype, c.value.datatype, c.value.text)
+	}
+	return nil
+}
+
+// compatible reports whether a literal of datatype lit may be compared with
+// a key of datatype key.
+func compatible(key, lit string) bool {
+	if key == lit {
+		return true
+	}
+	return key == ecs.DatatypeDecimal && lit == ecs.DatatypeInteger
+}
+
+type junction struct {
+	op    string
+	exprs []Expr
+}
+
+// And matches objects that match every expression.
+func And(exprs ...Expr) Expr {
+	return &junction{op: "and", exprs: exprs}
+}
+
+// Or matches objects that match any expression.
+func Or(exprs ...Expr) Expr {
+	return &junction{op: "or", exprs: exprs}
+}
+
+func (j *junction) String() string {
+	parts := make([]string, 0, len(j.exprs))
+	for _, e := range j.exprs {
+		if c, ok := e.(*junction); ok && len(c.exprs) > 1 {
+			parts = append(parts, "("+c.String()+")")
+			continue
+		}
+		parts = append(parts, e.String())
+	}
+	return strings.Join(parts, " "+j.op+" ")
+}
+
+func (j *junction) validate(s *Schema) error {
+	if len(j.exprs) == 0 {
+		return fmt.Errorf("mdsearch: %q requires at least one expression", j.op)
+	}
+	for _, e := range j.exprs {
+		if e == nil {
+			return fmt.Errorf("mdsearch: nil expression in %q", j.op)
+		}
+		if err := e.validate(s); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type group struct {
+	expr Expr
+}
+
+// Group encloses e in parentheses.
+func Group(e Expr) Expr {
+	return &group{expr: e}
+}
+
+func (g *group) String() string {
+	return "(" + g.expr.String() + ")"
+}
+
+func (g *group) validate(s *Schema) error {
+	if g.expr == nil {
+		return fmt.Errorf("mdsearch: nil expression in group")
+	}
+	return g.expr.validate(s)
+}
+
+// Validate checks e for malformed keys and literals. If s is not nil every
+// key must be indexed in s and every literal must match the key's datatype.
+func Validate(e Expr, s *Schema) error {
+	if e == nil {
+		return fmt.Errorf("mdsearch: empty query")
+	}
+	return e.validate(s)
+}
+
+// Build validates e against s and renders it for ListBucketQueryInput.SetQuery.
+func Build(e Expr, s *Schema) (string, error) {
+	if err := Validate(e, s); err != nil {
+		return "", err
+	}
+	return e.String(), nil
+}
+
+// Schema is the set of keys indexed for a bucket and their datatypes.
+type Schema struct {
+	keys map[string]string
+}
+
+// NewSchema returns a Schema built from keys, as returned by
+// ListBucketMetadataSearch or GetSystemMetadataSearchKeys.
+func NewSchema(keys []*ecs.EcsIndexableKey) *Schema {
+	s := &Schema{keys: map[string]string{}}
+	for _, k := range keys {
+		if k == nil || k.Name == nil {
+			continue
+		}
+		s.keys[strings.ToLower(*k.Name)] = ecs.NormalizeDatatype(aws.StringValue(k.Datatype))
+	}
+	return s
+}
+
+// Datatype returns the datatype of the indexed key name. A nil Schema has no
+// keys.
+func (s *Schema) Datatype(name string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	d, ok := s.keys[strings.ToLower(name)]
+	return d, ok
+}
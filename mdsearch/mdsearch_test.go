@@ -0,0 +1,70 @@
+package mdsearch_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/mdsearch"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+var schema = mdsearch.NewSchema([]*ecs.EcsIndexableKey{
+	{Name: aws.String("Size"), Datatype: aws.String("integer")},
+	{Name: aws.String("LastModified"), Datatype: aws.String("datetime")},
+	{Name: aws.String("x-amz-meta-STR"), Datatype: aws.String("string")},
+	{Name: aws.String("x-amz-meta-INT"), Datatype: aws.String("integer")},
+	{Name: aws.String("x-amz-meta-DEC"), Datatype: aws.String("decimal")},
+})
+
+func TestBuild(t *testing.T) {
+	modified := time.Date(2017, 6, 1, 10, 30, 0, 0, time.UTC)
+
+	q, err := mdsearch.Build(mdsearch.And(
+		mdsearch.Key("Size").Gt(3),
+		mdsearch.Or(
+			mdsearch.Key("x-amz-meta-STR").Eq(`say "hi"`),
+			mdsearch.Key("LastModified").Le(modified),
+		),
+		mdsearch.Key("x-amz-meta-DEC").Ge(1.5),
+	), schema)
+	assert.Nil(t, err)
+	assert.Equal(t, `Size > 3 and (x-amz-meta-STR == "say \"hi\"" or LastModified <= 2017-06-01T10:30:00Z) and x-amz-meta-DEC >= 1.5`, q)
+
+	q, err = mdsearch.Build(mdsearch.Group(mdsearch.Key("x-amz-meta-INT").Ne(mdsearch.IntegerValue(7))), schema)
+	assert.Nil(t, err)
+	assert.Equal(t, "(x-amz-meta-INT != 7)", q)
+
+	// integers are accepted for decimal keys
+	_, err = mdsearch.Build(mdsearch.Key("x-amz-meta-DEC").Lt(2), schema)
+	assert.Nil(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.Nil(t, mdsearch.Validate(mdsearch.Key("x-amz-meta-ANY").Eq("v"), nil))
+	assert.NotNil(t, mdsearch.Validate(mdsearch.Key("Size").Eq("big"), nil))
+	assert.NotNil(t, mdsearch.Validate(mdsearch.Key("Colour").Eq("red"), nil))
+	assert.NotNil(t, mdsearch.Validate(mdsearch.Key("x-amz-meta-a b").Eq("v"), nil))
+	assert.NotNil(t, mdsearch.Validate(mdsearch.Key("x-amz-meta-INT").Eq(struct{}{}), nil))
+	assert.NotNil(t, mdsearch.Validate(mdsearch.And(), nil))
+
+	assert.NotNil(t, mdsearch.Validate(mdsearch.Key("x-amz-meta-ANY").Eq("v"), schema))
+	assert.NotNil(t, mdsearch.Validate(mdsearch.Key("x-amz-meta-INT").Eq(1.5), schema))
+	assert.NotNil(t, mdsearch.Validate(mdsearch.Key("x-amz-meta-STR").Eq(time.Now()), schema))
+}
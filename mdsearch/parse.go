@@ -0,0 +1,212 @@
+package mdsearch
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/EMCECS/ecs-object-client-go"
+)
+
+// Parse parses a query in ECS syntax, such as the query of a
+// ListBucketQueryInput. Conditions are joined by "and" and "or", where "and"
+// binds tighter, and may be grouped by parentheses. String literals are
+// quoted with double or single quotes; unquoted literals are Integer,
+// Decimal or Datetime values. "=" is accepted for "==".
+//
+// Parse only checks the syntax of the query; use Validate to check it
+// against the keys indexed for a bucket.
+func Parse(query string) (Expr, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("mdsearch: empty query")
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("mdsearch: unexpected %q in query", p.tokens[p.pos].text)
+	}
+	return e, nil
+}
+
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits q into keys, operators, literals and parentheses.
+func tokenize(q string) ([]token, error) {
+	var tokens []token
+	rs := []rune(q)
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, token{text: string(c)})
+			i++
+		case strings.ContainsRune("=!<>", c):
+			j := i + 1
+			if j < len(rs) && rs[j] == '=' {
+				j++
+			}
+			tokens = append(tokens, token{text: string(rs[i:j])})
+			i = j
+		case c == '"' || c == '\'':
+			var sb strings.Builder
+			j := i + 1
+			for ; j < len(rs) && rs[j] != c; j++ {
+				if rs[j] == '\\' && j+1 < len(rs) {
+					j++
+				}
+				sb.WriteRune(rs[j])
+			}
+			if j == len(rs) {
+				return nil, fmt.Errorf("mdsearch: unterminated string in query")
+			}
+			tokens = append(tokens, token{text: sb.String(), quoted: true})
+			i = j + 1
+		default:
+			j := i
+			for j < len(rs) && !unicode.IsSpace(rs[j]) && !strings.ContainsRune("()=!<>\"'", rs[j]) {
+				j++
+			}
+			tokens = append(tokens, token{text: string(rs[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// keyword consumes the next token if it is the unquoted word, ignoring case.
+func (p *parser) keyword(word string) bool {
+	if p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, word) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) next() (token, error) {
+	if p.pos == len(p.tokens) {
+		return token{}, fmt.Errorf("mdsearch: unexpected end of query")
+	}
+	p.pos++
+	return p.tokens[p.pos-1], nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	return p.parseJunction("or", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	return p.parseJunction("and", p.parseFactor)
+}
+
+func (p *parser) parseJunction(op string, operand func() (Expr, error)) (Expr, error) {
+	j := &junction{op: op}
+	for {
+		e, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		j.exprs = append(j.exprs, e)
+		if !p.keyword(op) {
+			break
+		}
+	}
+	if len(j.exprs) == 1 {
+		return j.exprs[0], nil
+	}
+	return j, nil
+}
+
+func (p *parser) parseFactor() (Expr, error) {
+	if p.keyword("(") {
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.keyword(")") {
+			return nil, fmt.Errorf("mdsearch: missing ) in query")
+		}
+		return Group(e), nil
+	}
+	key, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if key.quoted || key.text == "(" || key.text == ")" {
+		return nil, fmt.Errorf("mdsearch: expected a key, got %q", key.text)
+	}
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.quoted {
+		return nil, fmt.Errorf("mdsearch: expected an operator after %q, got %q", key.text, op.text)
+	}
+	switch op.text {
+	case "=":
+		op.text = opEq
+	case opEq, opNe, opLt, opLe, opGt, opGe:
+	default:
+		return nil, fmt.Errorf("mdsearch: expected an operator after %q, got %q", key.text, op.text)
+	}
+	lit, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	v, err := parseLiteral(lit)
+	if err != nil {
+		return nil, err
+	}
+	return &condition{key: key.text, op: op.text, value: v}, nil
+}
+
+// parseLiteral types the literal t: quoted literals are String values,
+// unquoted ones Integer, Decimal or Datetime values.
+func parseLiteral(t token) (Value, error) {
+	if t.quoted {
+		return StringValue(t.text), nil
+	}
+	if _, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+		return literal(ecs.DatatypeInteger, t.text), nil
+	}
+	if _, err := strconv.ParseFloat(t.text, 64); err == nil {
+		return literal(ecs.DatatypeDecimal, t.text), nil
+	}
+	if _, err := time.Parse(ecs.DatetimeFormat, t.text); err == nil {
+		return literal(ecs.DatatypeDatetime, t.text), nil
+	}
+	return Value{}, fmt.Errorf("mdsearch: invalid literal %q, strings must be quoted", t.text)
+}
@@ -0,0 +1,84 @@
+package mdsearch_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go/mdsearch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, q := range []string{
+		`Size > 3`,
+		`Size > 3 and (x-amz-meta-STR == "say \"hi\"" or LastModified <= 2017-06-01T10:30:00Z) and x-amz-meta-DEC >= 1.5`,
+		`(x-amz-meta-INT != 7)`,
+	} {
+		e, err := mdsearch.Parse(q)
+		if assert.Nil(t, err, q) {
+			assert.Equal(t, q, e.String())
+		}
+	}
+
+	// "and" binds tighter than "or"
+	e, err := mdsearch.Parse(`ObjectName == "a" or ObjectName == "b" and Size < 10`)
+	if assert.Nil(t, err) {
+		assert.Equal(t, `ObjectName == "a" or (ObjectName == "b" and Size < 10)`, e.String())
+	}
+
+	e, err = mdsearch.Parse(`Size=3 AND x-amz-meta-str='red'`)
+	if assert.Nil(t, err) {
+		assert.Equal(t, `Size == 3 and x-amz-meta-str == "red"`, e.String())
+	}
+}
+
+func TestParseLiteral(t *testing.T) {
+	// unquoted literals are typed, so they only match keys of their datatype
+	for q, valid := range map[string]bool{
+		`Size > 3`:                              true,
+		`Size > "3"`:                            false,
+		`x-amz-meta-DEC > -1.5`:                 true,
+		`x-amz-meta-INT > 1.5`:                  false,
+		`LastModified > 2017-06-01T10:30:00Z`:   true,
+		`x-amz-meta-STR > 2017-06-01T10:30:00Z`: false,
+		`x-amz-meta-STR > "3"`:                  true,
+	} {
+		e, err := mdsearch.Parse(q)
+		if assert.Nil(t, err, q) {
+			assert.Equal(t, valid, mdsearch.Validate(e, schema) == nil, q)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, q := range []string{
+		``,
+		`Size`,
+		`Size >`,
+		`Size > red`,
+		`Size "==" 3`,
+		`"Size" == 3`,
+		`Size ~ 3`,
+		`(Size > 3`,
+		`Size > 3)`,
+		`Size > 3 and`,
+		`x-amz-meta-STR == "red`,
+	} {
+		_, err := mdsearch.Parse(q)
+		assert.NotNil(t, err, q)
+	}
+}
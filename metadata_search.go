@@ -0,0 +1,259 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// UserMetadataPrefix is the prefix of every user metadata key.
+const UserMetadataPrefix = "x-amz-meta-"
+
+// SystemMetadataSearchKeys maps the system metadata keys ECS can index to
+// their datatypes.
+var SystemMetadataSearchKeys = map[string]string{
+	"ObjectName":   DatatypeString,
+	"Owner":        DatatypeString,
+	"Size":         DatatypeInteger,
+	"CreateTime":   DatatypeDatetime,
+	"LastModified": DatatypeDatetime,
+}
+
+// SystemMetadataSearchKeyDatatype returns the datatype of the system metadata
+// key name, matched case-insensitively as ECS does, and whether name is an
+// indexable system key.
+func SystemMetadataSearchKeyDatatype(name string) (string, bool) {
+	if d, ok := SystemMetadataSearchKeys[name]; ok {
+		return d, true
+	}
+	for k, d := range SystemMetadataSearchKeys {
+		if strings.EqualFold(k, name) {
+			return d, true
+		}
+	}
+	return "", false
+}
+
+// NormalizeDatatype maps a datatype name reported by ECS, such as "integer",
+// onto the matching Datatype enum value. Unknown names are returned unchanged.
+func NormalizeDatatype(d string) string {
+	for _, v := range []string{DatatypeString, DatatypeInteger, DatatypeDecimal, DatatypeDatetime} {
+		if strings.EqualFold(d, v) {
+			return v
+		}
+	}
+	return d
+}
+
+// IsUserMetadataKey reports whether name is a user metadata key.
+func IsUserMetadataKey(name string) bool {
+	return strings.HasPrefix(strings.ToLower(name), UserMetadataPrefix)
+}
+
+// ValidateMetadataSearchKeyName checks that name is an indexable system key
+// or a well formed user metadata key.
+func ValidateMetadataSearchKeyName(name string) error {
+	if _, ok := SystemMetadataSearchKeyDatatype(name); ok {
+		return nil
+	}
+	if !IsUserMetadataKey(name) || len(name) == len(UserMetadataPrefix) {
+		return fmt.Errorf("invalid metadata search key %q", name)
+	}
+	for _, r := range name[len(UserMetadataPrefix):] {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return fmt.Errorf("invalid character %q in metadata search key %q", r, name)
+		}
+	}
+	return nil
+}
+
+// MetadataSearchIndex describes the keys a bucket indexes for metadata search.
+// It is sent as the x-emc-metadata-search header of CreateBucketExtension.
+type MetadataSearchIndex struct {
+	// System metadata keys to index, e.g. Size or LastModified.
+	SystemKeys []string
+	// User metadata keys to index together with their datatypes.
+	UserKeys []*MetadataSearchKey
+}
+
+// MetadataSearchKey is a user metadata key and the datatype it is indexed as.
+type MetadataSearchKey struct {
+	Name     string
+	Datatype string
+}
+
+// NewMetadataSearchIndex builds a MetadataSearchIndex from the IndexableKeys
+// returned by ListBucketMetadataSearch.
+func NewMetadataSearchIndex(keys []*EcsIndexableKey) *MetadataSearchIndex {
+	m := &MetadataSearchIndex{}
+	for _, k := range keys {
+		if k == nil || k.Name == nil {
+			continue
+		}
+		if IsUserMetadataKey(*k.Name) {
+			m.AddUserKey(*k.Name, NormalizeDatatype(aws.StringValue(k.Datatype)))
+		} else {
+			m.AddSystemKey(*k.Name)
+		}
+	}
+	return m
+}
+
+// ParseMetadataSearchIndex parses an x-emc-metadata-search header value such
+// as "Size,LastModified,x-amz-meta-STR;String".
+func ParseMetadataSearchIndex(v string) (*MetadataSearchIndex, error) {
+	m := &MetadataSearchIndex{}
+	for _, field := range strings.Split(v, ",") {
+		field = strings.TrimSpace(field)
+		parts := strings.Split(field, ";")
+		switch len(parts) {
+		case 1:
+			if IsUserMetadataKey(parts[0]) {
+				return nil, fmt.Errorf("user metadata key %q requires a datatype", parts[0])
+			}
+			m.AddSystemKey(parts[0])
+		case 2:
+			m.AddUserKey(strings.TrimSpace(parts[0]), NormalizeDatatype(strings.TrimSpace(parts[1])))
+		default:
+			return nil, fmt.Errorf("invalid metadata search key definition %q", field)
+		}
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AddSystemKey adds a system metadata key to the index.
+func (m *MetadataSearchIndex) AddSystemKey(name string) *MetadataSearchIndex {
+	m.SystemKeys = append(m.SystemKeys, name)
+	return m
+}
+
+// AddUserKey adds a user metadata key of the given datatype to the index.
+func (m *MetadataSearchIndex) AddUserKey(name, datatype string) *MetadataSearchIndex {
+	m.UserKeys = append(m.UserKeys, &MetadataSearchKey{Name: name, Datatype: datatype})
+	return m
+}
+
+// Validate checks key names, datatypes and duplicates.
+func (m *MetadataSearchIndex) Validate() error {
+	if len(m.SystemKeys)+len(m.UserKeys) == 0 {
+		return fmt.Errorf("metadata search index has no keys")
+	}
+	seen := map[string]bool{}
+	for _, k := range m.SystemKeys {
+		if _, ok := SystemMetadataSearchKeyDatatype(k); !ok {
+			return fmt.Errorf("%q is not an indexable system metadata key", k)
+		}
+		if seen[strings.ToLower(k)] {
+			return fmt.Errorf("duplicate metadata search key %q", k)
+		}
+		seen[strings.ToLower(k)] = true
+	}
+	for _, k := range m.UserKeys {
+		if err := ValidateMetadataSearchKeyName(k.Name); err != nil {
+			return err
+		}
+		if !IsUserMetadataKey(k.Name) {
+			return fmt.Errorf("%q is not a user metadata key", k.Name)
+		}
+		switch k.Datatype {
+		case DatatypeString, DatatypeInteger, DatatypeDecimal, DatatypeDatetime:
+		default:
+			return fmt.Errorf("invalid datatype %q for metadata search key %q", k.Datatype, k.Name)
+		}
+		if seen[strings.ToLower(k.Name)] {
+			return fmt.Errorf("duplicate metadata search key %q", k.Name)
+		}
+		seen[strings.ToLower(k.Name)] = true
+	}
+	return nil
+}
+
+// String returns the x-emc-metadata-search header value.
+func (m *MetadataSearchIndex) String() string {
+	fields := make([]string, 0, len(m.SystemKeys)+len(m.UserKeys))
+	fields = append(fields, m.SystemKeys...)
+	for _, k := range m.UserKeys {
+		fields = append(fields, k.Name+";"+k.Datatype)
+	}
+	return strings.Join(fields, ",")
+}
+
+// Diff compares m, the desired index, against actual. It returns the keys
+// missing from actual and the keys actual has that m does not. A user key
+// indexed with a different datatype is reported in both.
+func (m *MetadataSearchIndex) Diff(actual *MetadataSearchIndex) (missing, unexpected *MetadataSearchIndex) {
+	want, have := m.keySet(), actual.keySet()
+	missing, unexpected = &MetadataSearchIndex{}, &MetadataSearchIndex{}
+	for _, id := range sortedKeys(want) {
+		if _, ok := have[id]; !ok {
+			missing.add(want[id])
+		}
+	}
+	for _, id := range sortedKeys(have) {
+		if _, ok := want[id]; !ok {
+			unexpected.add(have[id])
+		}
+	}
+	return missing, unexpected
+}
+
+// Equal reports whether m and other index the same keys.
+func (m *MetadataSearchIndex) Equal(other *MetadataSearchIndex) bool {
+	missing, unexpected := m.Diff(other)
+	return len(missing.SystemKeys)+len(missing.UserKeys)+len(unexpected.SystemKeys)+len(unexpected.UserKeys) == 0
+}
+
+func (m *MetadataSearchIndex) add(k *MetadataSearchKey) {
+	if k.Datatype == "" {
+		m.AddSystemKey(k.Name)
+	} else {
+		m.AddUserKey(k.Name, k.Datatype)
+	}
+}
+
+// keySet indexes the keys of m by lower cased name and datatype.
+func (m *MetadataSearchIndex) keySet() map[string]*MetadataSearchKey {
+	set := map[string]*MetadataSearchKey{}
+	if m == nil {
+		return set
+	}
+	for _, k := range m.SystemKeys {
+		set[strings.ToLower(k)] = &MetadataSearchKey{Name: k}
+	}
+	for _, k := range m.UserKeys {
+		set[strings.ToLower(k.Name)+";"+NormalizeDatatype(k.Datatype)] = k
+	}
+	return set
+}
+
+func sortedKeys(m map[string]*MetadataSearchKey) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,81 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataSearchIndex(t *testing.T) {
+	header := "Size,CreateTime,LastModified,x-amz-meta-STR;String,x-amz-meta-INT;Integer"
+
+	index, err := ecs.ParseMetadataSearchIndex(header)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Size", "CreateTime", "LastModified"}, index.SystemKeys)
+	assert.Len(t, index.UserKeys, 2)
+	assert.Equal(t, header, index.String())
+
+	actual := (&ecs.ListBucketMetadataSearchOutput{
+		IndexableKeys: []*ecs.EcsIndexableKey{
+			{Name: aws.String("Size"), Datatype: aws.String("integer")},
+			{Name: aws.String("LastModified"), Datatype: aws.String("datetime")},
+			{Name: aws.String("x-amz-meta-STR"), Datatype: aws.String("string")},
+			{Name: aws.String("x-amz-meta-INT"), Datatype: aws.String("decimal")},
+		},
+	}).MetadataSearchIndex()
+
+	missing, unexpected := index.Diff(actual)
+	assert.Equal(t, "CreateTime,x-amz-meta-INT;Integer", missing.String())
+	assert.Equal(t, "x-amz-meta-INT;Decimal", unexpected.String())
+	assert.False(t, index.Equal(actual))
+	assert.True(t, actual.Equal(actual))
+
+	// System keys are matched case-insensitively, as ECS does.
+	index, err = ecs.ParseMetadataSearchIndex("size,createtime")
+	if assert.Nil(t, err) {
+		assert.Equal(t, "size,createtime", index.String())
+	}
+	datatype, ok := ecs.SystemMetadataSearchKeyDatatype("lastmodified")
+	assert.True(t, ok)
+	assert.Equal(t, ecs.DatatypeDatetime, datatype)
+	_, err = ecs.ParseMetadataSearchIndex("size,Size")
+	assert.NotNil(t, err)
+}
+
+func TestCreateBucketInputMetadataSearch(t *testing.T) {
+	input := (&ecs.CreateBucketInput{}).
+		SetBucket("bucket").
+		SetMetadataSearchIndex((&ecs.MetadataSearchIndex{}).
+			AddSystemKey("Size").
+			AddUserKey("x-amz-meta-STR", ecs.DatatypeString))
+	assert.Equal(t, "Size,x-amz-meta-STR;String", *input.MetadataSearch)
+	assert.Nil(t, input.Validate())
+	input.SetMetadataSearch("size,createtime")
+	assert.Nil(t, input.Validate())
+
+	for _, v := range []string{"", "Color", "x-amz-meta-STR", "x-amz-meta-STR;Float", "Size,Size"} {
+		input.SetMetadataSearch(v)
+		assert.NotNil(t, input.Validate(), v)
+	}
+
+	input.SetMetadataSearchIndex(nil)
+	assert.Nil(t, input.MetadataSearch)
+	assert.Nil(t, input.Validate())
+}
@@ -0,0 +1,236 @@
+package mgmt
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ByteSize is a size in bytes.
+type ByteSize int64
+
+// Byte size units. ECS sizes are binary, so a GB is 1024 MB.
+const (
+	Byte ByteSize = 1
+	KB            = 1024 * Byte
+	MB            = 1024 * KB
+	GB            = 1024 * MB
+	TB            = 1024 * GB
+	PB            = 1024 * TB
+)
+
+// GB returns the size in GB.
+func (b ByteSize) GB() float64 {
+	return float64(b) / float64(GB)
+}
+
+// String returns the size in the largest unit that keeps it at least 1,
+// e.g. "1.5 GB".
+func (b ByteSize) String() string {
+	units := []struct {
+		size ByteSize
+		name string
+	}{{PB, "PB"}, {TB, "TB"}, {GB, "GB"}, {MB, "MB"}, {KB, "KB"}}
+	for _, u := range units {
+		if b >= u.size || -b >= u.size {
+			return strconv.FormatFloat(float64(b)/float64(u.size), 'f', -1, 64) + " " + u.name
+		}
+	}
+	return fmt.Sprintf("%d B", int64(b))
+}
+
+// NoQuota is the quota size of a bucket without that quota.
+const NoQuota int64 = -1
+
+// BucketQuota is the quota of a bucket. Sizes are in GB; NoQuota means the
+// quota is not set.
+type BucketQuota struct {
+	Bucket    string `json:"bucketname,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	// BlockSize is the hard quota; writes fail once it is exceeded.
+	BlockSize int64 `json:"blockSize"`
+	// NotificationSize is the soft quota; exceeding it raises an alert.
+	NotificationSize int64 `json:"notificationSize"`
+}
+
+// HardLimit returns the hard quota, if set.
+func (q *BucketQuota) HardLimit() (ByteSize, bool) {
+	return quotaSize(q.BlockSize)
+}
+
+// SoftLimit returns the soft quota, if set.
+func (q *BucketQuota) SoftLimit() (ByteSize, bool) {
+	return quotaSize(q.NotificationSize)
+}
+
+func quotaSize(gb int64) (ByteSize, bool) {
+	if gb < 0 {
+		return 0, false
+	}
+	return ByteSize(gb) * GB, true
+}
+
+// Usage is the usage of a bucket or of a namespace, as last sampled by ECS.
+type Usage struct {
+	Namespace string
+	// Bucket is empty for namespace usage.
+	Bucket      string
+	ObjectCount int64
+	TotalSize   ByteSize
+	SampleTime  time.Time
+}
+
+// usageResponse is the billing info of a bucket or a namespace, requested
+// with sizes in KB.
+type usageResponse struct {
+	Namespace    string      `json:"namespace"`
+	Name         string      `json:"name"`
+	TotalObjects json.Number `json:"total_objects"`
+	TotalSize    json.Number `json:"total_size"`
+	SampleTime   string      `json:"sample_time"`
+}
+
+// sampleTimeFormats are the formats of the sample_time field.
+var sampleTimeFormats = []string{time.RFC3339, "2006-01-02T15:04"}
+
+func (r *usageResponse) usage() (*Usage, error) {
+	u := &Usage{Namespace: r.Namespace, Bucket: r.Name}
+	var err error
+	if r.TotalObjects != "" {
+		if u.ObjectCount, err = r.TotalObjects.Int64(); err != nil {
+			return nil, err
+		}
+	}
+	if r.TotalSize != "" {
+		kb, err := r.TotalSize.Float64()
+		if err != nil {
+			return nil, err
+		}
+		u.TotalSize = ByteSize(kb * float64(KB))
+	}
+	if r.SampleTime != "" {
+		for _, format := range sampleTimeFormats {
+			if u.SampleTime, err = time.Parse(format, r.SampleTime); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+func bucketQuotaPath(bucket string) string {
+	return "/object/bucket/" + url.PathEscape(bucket) + "/quota"
+}
+
+// GetBucketQuota returns the quota of a bucket.
+func (c *Client) GetBucketQuota(bucket, namespace string) (*BucketQuota, error) {
+	return c.GetBucketQuotaWithContext(aws.BackgroundContext(), bucket, namespace)
+}
+
+// GetBucketQuotaWithContext is the same as GetBucketQuota with the addition of
+// the ability to pass a context.
+func (c *Client) GetBucketQuotaWithContext(ctx aws.Context, bucket, namespace string) (*BucketQuota, error) {
+	if err := validateParams("GetBucketQuota", param{"Bucket", bucket}, param{"Namespace", namespace}); err != nil {
+		return nil, err
+	}
+	quota := &BucketQuota{BlockSize: NoQuota, NotificationSize: NoQuota}
+	if err := c.do(ctx, "GET", bucketQuotaPath(bucket), namespaceQuery(namespace), nil, quota); err != nil {
+		return nil, err
+	}
+	return quota, nil
+}
+
+// PutBucketQuota sets the quota of quota.Bucket. Use NoQuota for a size to
+// leave that quota unset.
+func (c *Client) PutBucketQuota(quota *BucketQuota) error {
+	return c.PutBucketQuotaWithContext(aws.BackgroundContext(), quota)
+}
+
+// PutBucketQuotaWithContext is the same as PutBucketQuota with the addition of
+// the ability to pass a context.
+func (c *Client) PutBucketQuotaWithContext(ctx aws.Context, quota *BucketQuota) error {
+	if quota == nil {
+		invalidParams := request.ErrInvalidParams{Context: "PutBucketQuota"}
+		invalidParams.Add(request.NewErrParamRequired("Quota"))
+		return invalidParams
+	}
+	if err := validateParams("PutBucketQuota", param{"Bucket", quota.Bucket}, param{"Namespace", quota.Namespace}); err != nil {
+		return err
+	}
+	return c.do(ctx, "PUT", bucketQuotaPath(quota.Bucket), nil, quota, nil)
+}
+
+// DeleteBucketQuota removes the quota of a bucket.
+func (c *Client) DeleteBucketQuota(bucket, namespace string) error {
+	return c.DeleteBucketQuotaWithContext(aws.BackgroundContext(), bucket, namespace)
+}
+
+// DeleteBucketQuotaWithContext is the same as DeleteBucketQuota with the addition of
+// the ability to pass a context.
+func (c *Client) DeleteBucketQuotaWithContext(ctx aws.Context, bucket, namespace string) error {
+	if err := validateParams("DeleteBucketQuota", param{"Bucket", bucket}, param{"Namespace", namespace}); err != nil {
+		return err
+	}
+	return c.do(ctx, "DELETE", bucketQuotaPath(bucket), namespaceQuery(namespace), nil, nil)
+}
+
+// GetBucketUsage returns the usage of a bucket.
+func (c *Client) GetBucketUsage(bucket, namespace string) (*Usage, error) {
+	return c.GetBucketUsageWithContext(aws.BackgroundContext(), bucket, namespace)
+}
+
+// GetBucketUsageWithContext is the same as GetBucketUsage with the addition of
+// the ability to pass a context.
+func (c *Client) GetBucketUsageWithContext(ctx aws.Context, bucket, namespace string) (*Usage, error) {
+	if err := validateParams("GetBucketUsage", param{"Bucket", bucket}, param{"Namespace", namespace}); err != nil {
+		return nil, err
+	}
+	path := "/object/billing/buckets/" + url.PathEscape(namespace) + "/" + url.PathEscape(bucket) + "/info"
+	return c.usage(ctx, path, url.Values{"sizeunit": {"KB"}})
+}
+
+// GetNamespaceUsage returns the usage of a namespace.
+func (c *Client) GetNamespaceUsage(namespace string) (*Usage, error) {
+	return c.GetNamespaceUsageWithContext(aws.BackgroundContext(), namespace)
+}
+
+// GetNamespaceUsageWithContext is the same as GetNamespaceUsage with the addition of
+// the ability to pass a context.
+func (c *Client) GetNamespaceUsageWithContext(ctx aws.Context, namespace string) (*Usage, error) {
+	if err := validateParams("GetNamespaceUsage", param{"Namespace", namespace}); err != nil {
+		return nil, err
+	}
+	path := "/object/billing/namespace/" + url.PathEscape(namespace) + "/info"
+	return c.usage(ctx, path, url.Values{"sizeunit": {"KB"}, "include_bucket_detail": {"false"}})
+}
+
+func (c *Client) usage(ctx aws.Context, path string, query url.Values) (*Usage, error) {
+	var resp usageResponse
+	if err := c.do(ctx, "GET", path, query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.usage()
+}
@@ -0,0 +1,132 @@
+package mgmt_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go/mgmt"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByteSize(t *testing.T) {
+	assert.Equal(t, "512 B", mgmt.ByteSize(512).String())
+	assert.Equal(t, "1.5 KB", mgmt.ByteSize(1536).String())
+	assert.Equal(t, "2 GB", (2 * mgmt.GB).String())
+	assert.Equal(t, 0.5, (512 * mgmt.MB).GB())
+}
+
+func TestMgmtBucketQuota(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	stored := map[string]interface{}{"bucketname": "bucket", "namespace": "ns1", "blockSize": -1, "notificationSize": 5}
+	fake.handlers["GET /object/bucket/bucket/quota"] = func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ns1", r.URL.Query().Get("namespace"))
+		writeJSON(w, stored)
+	}
+	fake.handlers["PUT /object/bucket/bucket/quota"] = func(w http.ResponseWriter, r *http.Request) {
+		stored = map[string]interface{}{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&stored))
+	}
+	fake.handlers["DELETE /object/bucket/bucket/quota"] = func(w http.ResponseWriter, r *http.Request) {
+		stored = map[string]interface{}{"bucketname": "bucket", "namespace": "ns1"}
+	}
+	client := newClient(server.URL)
+
+	quota, err := client.GetBucketQuota("bucket", "ns1")
+	assert.Nil(t, err)
+	_, ok := quota.HardLimit()
+	assert.False(t, ok)
+	soft, ok := quota.SoftLimit()
+	assert.True(t, ok)
+	assert.Equal(t, 5*mgmt.GB, soft)
+
+	assert.Nil(t, client.PutBucketQuota(&mgmt.BucketQuota{Bucket: "bucket", Namespace: "ns1", BlockSize: 10, NotificationSize: 8}))
+	assert.Equal(t, float64(10), stored["blockSize"])
+	assert.Equal(t, float64(8), stored["notificationSize"])
+
+	assert.Nil(t, client.DeleteBucketQuota("bucket", "ns1"))
+	quota, err = client.GetBucketQuota("bucket", "ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, mgmt.NoQuota, quota.BlockSize)
+	assert.Equal(t, mgmt.NoQuota, quota.NotificationSize)
+}
+
+func TestMgmtBucketQuotaEmptyNames(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	client := newClient(server.URL)
+
+	_, err := client.GetBucketQuota("", "ns1")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	err = client.PutBucketQuota(&mgmt.BucketQuota{Bucket: "bucket", BlockSize: 10})
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	err = client.PutBucketQuota(nil)
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	err = client.DeleteBucketQuota("", "")
+	if assert.IsType(t, request.ErrInvalidParams{}, err) {
+		assert.Equal(t, 2, err.(request.ErrInvalidParams).Len())
+	}
+	_, err = client.GetBucketUsage("bucket", "")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	_, err = client.GetNamespaceUsage("")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+
+	logins, _ := fake.sessions()
+	assert.Equal(t, 0, logins)
+}
+
+func TestMgmtUsage(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	fake.handlers["GET /object/billing/buckets/ns1/bucket/info"] = func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "KB", r.URL.Query().Get("sizeunit"))
+		writeJSON(w, map[string]string{
+			"namespace":     "ns1",
+			"name":          "bucket",
+			"total_objects": "12",
+			"total_size":    "2048.5",
+			"sample_time":   "2017-03-01T10:15:00Z",
+		})
+	}
+	fake.handlers["GET /object/billing/namespace/ns1/info"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{
+			"namespace":     "ns1",
+			"total_objects": 40,
+			"total_size":    1048576,
+			"sample_time":   "2017-03-01T10:00",
+		})
+	}
+	client := newClient(server.URL)
+
+	usage, err := client.GetBucketUsage("bucket", "ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, "bucket", usage.Bucket)
+	assert.Equal(t, int64(12), usage.ObjectCount)
+	assert.Equal(t, 2*mgmt.MB+512, usage.TotalSize)
+	assert.Equal(t, time.Date(2017, 3, 1, 10, 15, 0, 0, time.UTC), usage.SampleTime)
+
+	usage, err = client.GetNamespaceUsage("ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, "", usage.Bucket)
+	assert.Equal(t, int64(40), usage.ObjectCount)
+	assert.Equal(t, mgmt.GB, usage.TotalSize)
+	assert.Equal(t, time.Date(2017, 3, 1, 10, 0, 0, 0, time.UTC), usage.SampleTime)
+}
@@ -0,0 +1,273 @@
+// Package mgmt provides a client for the ECS Management REST API.
+package mgmt
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// AuthTokenHeader is the header carrying the management session token.
+const AuthTokenHeader = "X-SDS-AUTH-TOKEN"
+
+// Config holds the endpoint and credentials of the management API.
+type Config struct {
+	// Endpoint of the management API, e.g. https://ecs.example.com:4443
+	Endpoint string
+	// Username of a management user.
+	Username string
+	// Password of the management user.
+	Password string
+	// HTTPClient used to send requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// Client is a client for the ECS Management REST API. It logs in on the
+// first request and logs in again when the session token expires. A Client
+// is safe for concurrent use.
+type Client struct {
+	config Config
+
+	mu    sync.Mutex
+	token string
+}
+
+// New returns a Client for config.
+func New(config Config) *Client {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+	config.Endpoint = strings.TrimRight(config.Endpoint, "/")
+	return &Client{config: config}
+}
+
+// Error is an error returned by the management API.
+type Error struct {
+	// HTTP status code of the response.
+	StatusCode int `json:"-"`
+	// ECS service code of the error.
+	Code        int    `json:"code"`
+	Retryable   bool   `json:"retryable"`
+	Description string `json:"description"`
+	Details     string `json:"details"`
+}
+
+// Error returns the string representation of the error.
+func (e *Error) Error() string {
+	return fmt.Sprintf("ecs management: %d %s (code %d): %s", e.StatusCode, e.Description, e.Code, e.Details)
+}
+
+// Link is a reference to a resource of the management API.
+type Link struct {
+	Rel  string `json:"rel"`
+	Href string `json:"href"`
+}
+
+// Login starts a session. It is called automatically by the other methods.
+func (c *Client) Login() error {
+	return c.LoginWithContext(aws.BackgroundContext())
+}
+
+// LoginWithContext is the same as Login with the addition of
+// the ability to pass a context.
+func (c *Client) LoginWithContext(ctx aws.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.login(ctx)
+}
+
+// login must be called with c.mu held.
+func (c *Client) login(ctx aws.Context) error {
+	req, err := http.NewRequest("GET", c.config.Endpoint+"/login", nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.config.Username, c.config.Password)
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+
+	token := resp.Header.Get(AuthTokenHeader)
+	if token == "" {
+		return fmt.Errorf("ecs management: login response has no %s header", AuthTokenHeader)
+	}
+	c.token = token
+	return nil
+}
+
+// Logout ends the session, if any.
+func (c *Client) Logout() error {
+	return c.LogoutWithContext(aws.BackgroundContext())
+}
+
+// LogoutWithContext is the same as Logout with the addition of
+// the ability to pass a context.
+func (c *Client) LogoutWithContext(ctx aws.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" {
+		return nil
+	}
+
+	req, err := http.NewRequest("GET", c.config.Endpoint+"/logout", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(AuthTokenHeader, c.token)
+	c.token = ""
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return checkResponse(resp)
+}
+
+// sessionToken returns the current token, logging in if there is none.
+func (c *Client) sessionToken(ctx aws.Context) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == "" {
+		if err := c.login(ctx); err != nil {
+			return "", err
+		}
+	}
+	return c.token, nil
+}
+
+// expire forgets token unless another request already replaced it.
+func (c *Client) expire(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token == token {
+		c.token = ""
+	}
+}
+
+// do sends a JSON request to path and decodes the response into out. When the
+// session token was rejected it logs in again and retries once.
+func (c *Client) do(ctx aws.Context, method, path string, query url.Values, in, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		if body, err = json.Marshal(in); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		token, err := c.sessionToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		u := c.config.Endpoint + path
+		if len(query) > 0 {
+			u += "?" + query.Encode()
+		}
+		req, err := http.NewRequest(method, u, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set(AuthTokenHeader, token)
+		req.Header.Set("Accept", "application/json")
+		if in != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := c.send(ctx, req)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			drain(resp.Body)
+			c.expire(token)
+			continue
+		}
+		return decodeResponse(resp, out)
+	}
+}
+
+func (c *Client) send(ctx aws.Context, req *http.Request) (*http.Response, error) {
+	return c.config.HTTPClient.Do(req.WithContext(ctx))
+}
+
+func decodeResponse(resp *http.Response, out interface{}) error {
+	defer drain(resp.Body)
+	if err := checkResponse(resp); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// checkResponse returns an *Error for unsuccessful responses.
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+	e := &Error{StatusCode: resp.StatusCode}
+	b, _ := ioutil.ReadAll(resp.Body)
+	if json.Unmarshal(b, e) != nil || e.Description == "" {
+		e.Description = http.StatusText(resp.StatusCode)
+		e.Details = strings.TrimSpace(string(b))
+	}
+	return e
+}
+
+func drain(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, body)
+	body.Close()
+}
+
+// param is a named argument of an operation.
+type param struct {
+	name, value string
+}
+
+// validateParams fails with an ErrInvalidParams for the operation op if any
+// of params is empty. They name the resource of the request, such as a bucket,
+// user or namespace, so an empty one would address another resource.
+func validateParams(op string, params ...param) error {
+	invalidParams := request.ErrInvalidParams{Context: op}
+	for _, p := range params {
+		if p.value == "" {
+			invalidParams.Add(request.NewErrParamMinLen(p.name, 1))
+		}
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package mgmt_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go/mgmt"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeMgmt is a minimal stand-in for the management API. Each login issues a
+// new token; only the latest token is accepted.
+type fakeMgmt struct {
+	mu       sync.Mutex
+	logins   int
+	logouts  int
+	token    string
+	handlers map[string]http.HandlerFunc
+}
+
+func newFakeMgmt() (*fakeMgmt, *httptest.Server) {
+	f := &fakeMgmt{handlers: map[string]http.HandlerFunc{}}
+	return f, httptest.NewServer(f)
+}
+
+func (f *fakeMgmt) expireToken() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.token = ""
+}
+
+// sessions returns the number of logins and logouts so far.
+func (f *fakeMgmt) sessions() (logins, logouts int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.logins, f.logouts
+}
+
+func (f *fakeMgmt) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/login":
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		f.logins++
+		f.token = "token-" + strconv.Itoa(f.logins)
+		w.Header().Set(mgmt.AuthTokenHeader, f.token)
+		return
+	case "/logout":
+		f.logouts++
+		f.token = ""
+		return
+	}
+	if f.token == "" || r.Header.Get(mgmt.AuthTokenHeader) != f.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	handler, ok := f.handlers[r.Method+" "+r.URL.Path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":1004,"retryable":false,"description":"Unable to find entity","details":"not found"}`))
+		return
+	}
+	handler(w, r)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func newClient(url string) *mgmt.Client {
+	return mgmt.New(mgmt.Config{Endpoint: url + "/", Username: "admin", Password: "secret"})
+}
+
+func TestMgmtLogin(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	fake.handlers["GET /object/namespaces"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"namespace": []map[string]string{}})
+	}
+	client := newClient(server.URL)
+
+	_, err := client.ListNamespaces()
+	assert.Nil(t, err)
+	_, err = client.ListNamespaces()
+	assert.Nil(t, err)
+	logins, _ := fake.sessions()
+	assert.Equal(t, 1, logins)
+
+	fake.expireToken()
+	_, err = client.ListNamespaces()
+	assert.Nil(t, err)
+	logins, _ = fake.sessions()
+	assert.Equal(t, 2, logins)
+
+	assert.Nil(t, client.Logout())
+	_, logouts := fake.sessions()
+	assert.Equal(t, 1, logouts)
+	assert.Nil(t, client.Logout())
+	_, logouts = fake.sessions()
+	assert.Equal(t, 1, logouts)
+}
+
+func TestMgmtLoginFailure(t *testing.T) {
+	_, server := newFakeMgmt()
+	defer server.Close()
+	client := mgmt.New(mgmt.Config{Endpoint: server.URL, Username: "admin", Password: "wrong"})
+
+	_, err := client.ListNamespaces()
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, err.(*mgmt.Error).StatusCode)
+}
+
+func TestMgmtError(t *testing.T) {
+	_, server := newFakeMgmt()
+	defer server.Close()
+	client := newClient(server.URL)
+
+	_, err := client.GetNamespace("missing")
+	e, ok := err.(*mgmt.Error)
+	assert.True(t, ok)
+	assert.Equal(t, http.StatusNotFound, e.StatusCode)
+	assert.Equal(t, 1004, e.Code)
+	assert.Equal(t, "not found", e.Details)
+}
+
+func TestMgmtNamespaces(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	fake.handlers["GET /object/namespaces"] = func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("marker") == "" {
+			writeJSON(w, map[string]interface{}{
+				"namespace":  []map[string]string{{"id": "ns1", "name": "ns1"}},
+				"NextMarker": "ns2",
+			})
+			return
+		}
+		writeJSON(w, map[string]interface{}{"namespace": []map[string]string{{"id": "ns2", "name": "ns2"}}})
+	}
+	fake.handlers["POST /object/namespaces/namespace"] = func(w http.ResponseWriter, r *http.Request) {
+		var input mgmt.CreateNamespaceInput
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.Equal(t, "rg1", input.DefaultDataServicesVPool)
+		writeJSON(w, map[string]string{"id": input.Namespace, "name": input.Namespace})
+	}
+	fake.handlers["GET /object/namespaces/namespace/ns3"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"id": "ns3", "name": "ns3", "is_stale_allowed": true})
+	}
+	deleted := false
+	fake.handlers["POST /object/namespaces/namespace/ns3/deactivate"] = func(w http.ResponseWriter, r *http.Request) {
+		deleted = true
+	}
+	client := newClient(server.URL)
+
+	namespaces, err := client.ListNamespaces()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(namespaces))
+	assert.Equal(t, "ns2", namespaces[1].ID)
+
+	ns, err := client.CreateNamespace(&mgmt.CreateNamespaceInput{Namespace: "ns3", DefaultDataServicesVPool: "rg1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "ns3", ns.ID)
+
+	ns, err = client.GetNamespace("ns3")
+	assert.Nil(t, err)
+	assert.True(t, ns.IsStaleAllowed)
+
+	assert.Nil(t, client.DeleteNamespace("ns3"))
+	assert.True(t, deleted)
+}
+
+func TestMgmtObjectUsers(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	fake.handlers["GET /object/users"] = func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ns1", r.URL.Query().Get("namespace"))
+		writeJSON(w, map[string]interface{}{"blobuser": []map[string]string{{"userid": "user1", "namespace": "ns1"}}})
+	}
+	fake.handlers["GET /object/users/user1/info"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]interface{}{"name": "user1", "namespace": "ns1", "locked": true})
+	}
+	fake.handlers["GET /object/user-secret-keys/user1/ns1"] = func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]string{"secret_key_1": "key1", "key_timestamp_1": "2017-01-01 00:00:00.000", "secret_key_2": ""})
+	}
+	fake.handlers["POST /object/users"] = func(w http.ResponseWriter, r *http.Request) {
+		var input mgmt.CreateObjectUserInput
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.Equal(t, mgmt.CreateObjectUserInput{User: "user2", Namespace: "ns1"}, input)
+		writeJSON(w, map[string]interface{}{"link": map[string]string{"rel": "self", "href": "/object/users/user2"}})
+	}
+	deleted := ""
+	fake.handlers["POST /object/users/deactivate"] = func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&body))
+		deleted = body["user"] + "@" + body["namespace"]
+	}
+	client := newClient(server.URL)
+
+	user, err := client.CreateObjectUser(&mgmt.CreateObjectUserInput{User: "user2", Namespace: "ns1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "user2", user.UserID)
+	assert.Nil(t, client.DeleteObjectUser("user2", "ns1"))
+	assert.Equal(t, "user2@ns1", deleted)
+
+	users, err := client.ListObjectUsers("ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, []*mgmt.ObjectUser{{UserID: "user1", Namespace: "ns1"}}, users)
+
+	info, err := client.GetObjectUserInfo("user1", "ns1")
+	assert.Nil(t, err)
+	assert.True(t, info.Locked)
+
+	keys, err := client.GetSecretKeys("user1", "ns1")
+	assert.Nil(t, err)
+	assert.Equal(t, "key1", keys.SecretKey1)
+	assert.Equal(t, "", keys.SecretKey2)
+}
@@ -0,0 +1,119 @@
+package mgmt
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+// Namespace describes an ECS namespace.
+type Namespace struct {
+	ID                       string   `json:"id"`
+	Name                     string   `json:"name"`
+	Link                     *Link    `json:"link,omitempty"`
+	Inactive                 bool     `json:"inactive"`
+	DefaultDataServicesVPool string   `json:"default_data_services_vpool,omitempty"`
+	AllowedVPools            []string `json:"allowed_vpools_list,omitempty"`
+	DisallowedVPools         []string `json:"disallowed_vpools_list,omitempty"`
+	NamespaceAdmins          string   `json:"namespace_admins,omitempty"`
+	IsEncryptionEnabled      bool     `json:"is_encryption_enabled"`
+	DefaultBucketBlockSize   int64    `json:"default_bucket_block_size"`
+	IsStaleAllowed           bool     `json:"is_stale_allowed"`
+	IsComplianceEnabled      bool     `json:"is_compliance_enabled"`
+}
+
+// CreateNamespaceInput holds the settings of a new namespace.
+type CreateNamespaceInput struct {
+	// Namespace is the name of the namespace.
+	Namespace                string `json:"namespace"`
+	DefaultDataServicesVPool string `json:"default_data_services_vpool"`
+	NamespaceAdmins          string `json:"namespace_admins,omitempty"`
+	IsEncryptionEnabled      bool   `json:"is_encryption_enabled"`
+	DefaultBucketBlockSize   int64  `json:"default_bucket_block_size,omitempty"`
+	IsStaleAllowed           bool   `json:"is_stale_allowed"`
+	IsComplianceEnabled      bool   `json:"is_compliance_enabled"`
+}
+
+type namespaceList struct {
+	Namespaces []*Namespace `json:"namespace"`
+	NextMarker string       `json:"NextMarker"`
+}
+
+// ListNamespaces returns all namespaces.
+func (c *Client) ListNamespaces() ([]*Namespace, error) {
+	return c.ListNamespacesWithContext(aws.BackgroundContext())
+}
+
+// ListNamespacesWithContext is the same as ListNamespaces with the addition of
+// the ability to pass a context.
+func (c *Client) ListNamespacesWithContext(ctx aws.Context) ([]*Namespace, error) {
+	var all []*Namespace
+	query := url.Values{}
+	for {
+		var page namespaceList
+		if err := c.do(ctx, "GET", "/object/namespaces", query, nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Namespaces...)
+		if page.NextMarker == "" {
+			return all, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+// GetNamespace returns the namespace with the given id.
+func (c *Client) GetNamespace(id string) (*Namespace, error) {
+	return c.GetNamespaceWithContext(aws.BackgroundContext(), id)
+}
+
+// GetNamespaceWithContext is the same as GetNamespace with the addition of
+// the ability to pass a context.
+func (c *Client) GetNamespaceWithContext(ctx aws.Context, id string) (*Namespace, error) {
+	ns := &Namespace{}
+	if err := c.do(ctx, "GET", "/object/namespaces/namespace/"+url.PathEscape(id), nil, nil, ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// CreateNamespace creates a namespace.
+func (c *Client) CreateNamespace(input *CreateNamespaceInput) (*Namespace, error) {
+	return c.CreateNamespaceWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateNamespaceWithContext is the same as CreateNamespace with the addition of
+// the ability to pass a context.
+func (c *Client) CreateNamespaceWithContext(ctx aws.Context, input *CreateNamespaceInput) (*Namespace, error) {
+	ns := &Namespace{}
+	if err := c.do(ctx, "POST", "/object/namespaces/namespace", nil, input, ns); err != nil {
+		return nil, err
+	}
+	return ns, nil
+}
+
+// DeleteNamespace deactivates and deletes the namespace with the given id.
+func (c *Client) DeleteNamespace(id string) error {
+	return c.DeleteNamespaceWithContext(aws.BackgroundContext(), id)
+}
+
+// DeleteNamespaceWithContext is the same as DeleteNamespace with the addition of
+// the ability to pass a context.
+func (c *Client) DeleteNamespaceWithContext(ctx aws.Context, id string) error {
+	return c.do(ctx, "POST", "/object/namespaces/namespace/"+url.PathEscape(id)+"/deactivate", nil, nil, nil)
+}
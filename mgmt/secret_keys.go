@@ -0,0 +1,173 @@
+package mgmt
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// oldest returns the older of two keys in use, or "" if a slot is free.
+// ECS timestamps ("2006-01-02 15:04:05.000") sort lexically.
+func (k *SecretKeys) oldest() string {
+	if k.SecretKey1 == "" || k.SecretKey2 == "" {
+		return ""
+	}
+	if k.KeyTimestamp2 < k.KeyTimestamp1 {
+		return k.SecretKey2
+	}
+	return k.SecretKey1
+}
+
+// SecretKey is a secret key created for an object user.
+type SecretKey struct {
+	// UserID of the object user owning the key.
+	UserID             string `json:"-"`
+	SecretKey          string `json:"secret_key"`
+	KeyTimestamp       string `json:"key_timestamp"`
+	KeyExpiryTimestamp string `json:"key_expiry_timestamp"`
+	Link               *Link  `json:"link,omitempty"`
+}
+
+// Credentials returns static S3 credentials for the key, ready to be used in
+// the aws.Config of ecs.New.
+func (k *SecretKey) Credentials() *credentials.Credentials {
+	return credentials.NewStaticCredentials(k.UserID, k.SecretKey, "")
+}
+
+// CreateSecretKeyInput holds the settings of a new secret key.
+type CreateSecretKeyInput struct {
+	Namespace string `json:"namespace,omitempty"`
+	// ExistingKeyExpiryMinutes is how long the user's existing key stays valid.
+	// The existing key does not expire if nil.
+	ExistingKeyExpiryMinutes *int64 `json:"existing_key_expiry_time_mins,omitempty"`
+	// SecretKey to use, generated by ECS if empty.
+	SecretKey string `json:"secretkey,omitempty"`
+}
+
+type secretKeyRef struct {
+	Namespace string `json:"namespace,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+func secretKeysPath(uid string) string {
+	return "/object/user-secret-keys/" + url.PathEscape(uid)
+}
+
+// CreateSecretKey creates a secret key for an object user. It fails if the
+// user already has two keys.
+func (c *Client) CreateSecretKey(uid string, input *CreateSecretKeyInput) (*SecretKey, error) {
+	return c.CreateSecretKeyWithContext(aws.BackgroundContext(), uid, input)
+}
+
+// CreateSecretKeyWithContext is the same as CreateSecretKey with the addition of
+// the ability to pass a context.
+func (c *Client) CreateSecretKeyWithContext(ctx aws.Context, uid string, input *CreateSecretKeyInput) (*SecretKey, error) {
+	if err := validateParams("CreateSecretKey", param{"UserID", uid}); err != nil {
+		return nil, err
+	}
+	key := &SecretKey{}
+	if err := c.do(ctx, "POST", secretKeysPath(uid), nil, input, key); err != nil {
+		return nil, err
+	}
+	key.UserID = uid
+	return key, nil
+}
+
+// ExpireSecretKey deletes secretKey of an object user immediately.
+func (c *Client) ExpireSecretKey(uid, namespace, secretKey string) error {
+	return c.ExpireSecretKeyWithContext(aws.BackgroundContext(), uid, namespace, secretKey)
+}
+
+// ExpireSecretKeyWithContext is the same as ExpireSecretKey with the addition of
+// the ability to pass a context.
+func (c *Client) ExpireSecretKeyWithContext(ctx aws.Context, uid, namespace, secretKey string) error {
+	if err := validateParams("ExpireSecretKey", param{"UserID", uid}, param{"Namespace", namespace}, param{"SecretKey", secretKey}); err != nil {
+		return err
+	}
+	return c.do(ctx, "POST", secretKeysPath(uid)+"/deactivate", nil, &secretKeyRef{Namespace: namespace, SecretKey: secretKey}, nil)
+}
+
+// ExpireAllSecretKeys deletes all the secret keys of an object user
+// immediately.
+func (c *Client) ExpireAllSecretKeys(uid, namespace string) error {
+	return c.ExpireAllSecretKeysWithContext(aws.BackgroundContext(), uid, namespace)
+}
+
+// ExpireAllSecretKeysWithContext is the same as ExpireAllSecretKeys with the addition of
+// the ability to pass a context.
+func (c *Client) ExpireAllSecretKeysWithContext(ctx aws.Context, uid, namespace string) error {
+	if err := validateParams("ExpireAllSecretKeys", param{"UserID", uid}, param{"Namespace", namespace}); err != nil {
+		return err
+	}
+	return c.do(ctx, "POST", secretKeysPath(uid)+"/deactivate", nil, &secretKeyRef{Namespace: namespace}, nil)
+}
+
+// RotateSecretKey creates a new secret key for an object user and keeps the
+// current key valid for grace, rounded up to whole minutes, so clients can
+// switch over; grace must not be negative. If the user already has two keys
+// the older one is expired first, since ECS keeps at most two.
+func (c *Client) RotateSecretKey(uid, namespace string, grace time.Duration) (*SecretKey, error) {
+	return c.RotateSecretKeyWithContext(aws.BackgroundContext(), uid, namespace, grace)
+}
+
+// RotateSecretKeyWithContext is the same as RotateSecretKey with the addition of
+// the ability to pass a context.
+func (c *Client) RotateSecretKeyWithContext(ctx aws.Context, uid, namespace string, grace time.Duration) (*SecretKey, error) {
+	if err := validateParams("RotateSecretKey", param{"UserID", uid}, param{"Namespace", namespace}); err != nil {
+		return nil, err
+	}
+	if grace < 0 {
+		invalidParams := request.ErrInvalidParams{Context: "RotateSecretKey"}
+		invalidParams.Add(request.NewErrParamMinValue("Grace", 0))
+		return nil, invalidParams
+	}
+	keys, err := c.GetSecretKeysWithContext(ctx, uid, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if oldest := keys.oldest(); oldest != "" {
+		if err := c.ExpireSecretKeyWithContext(ctx, uid, namespace, oldest); err != nil {
+			return nil, err
+		}
+	}
+
+	minutes := int64((grace + time.Minute - 1) / time.Minute)
+	return c.CreateSecretKeyWithContext(ctx, uid, &CreateSecretKeyInput{
+		Namespace:                namespace,
+		ExistingKeyExpiryMinutes: aws.Int64(minutes),
+	})
+}
+
+// NewCredentials creates a secret key for an object user and returns
+// credentials using it.
+func (c *Client) NewCredentials(uid, namespace string) (*credentials.Credentials, error) {
+	return c.NewCredentialsWithContext(aws.BackgroundContext(), uid, namespace)
+}
+
+// NewCredentialsWithContext is the same as NewCredentials with the addition of
+// the ability to pass a context.
+func (c *Client) NewCredentialsWithContext(ctx aws.Context, uid, namespace string) (*credentials.Credentials, error) {
+	key, err := c.CreateSecretKeyWithContext(ctx, uid, &CreateSecretKeyInput{Namespace: namespace})
+	if err != nil {
+		return nil, err
+	}
+	return key.Credentials(), nil
+}
@@ -0,0 +1,160 @@
+package mgmt_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go/mgmt"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeKeys models the two secret key slots of one object user.
+type fakeKeys struct {
+	created int
+	keys    []map[string]string
+	expiry  []int64
+}
+
+func (k *fakeKeys) register(t *testing.T, fake *fakeMgmt) {
+	fake.handlers["GET /object/user-secret-keys/user1/ns1"] = func(w http.ResponseWriter, r *http.Request) {
+		out := map[string]string{}
+		for i, key := range k.keys {
+			out[fmt.Sprintf("secret_key_%d", i+1)] = key["secret_key"]
+			out[fmt.Sprintf("key_timestamp_%d", i+1)] = key["key_timestamp"]
+		}
+		writeJSON(w, out)
+	}
+	fake.handlers["POST /object/user-secret-keys/user1"] = func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]interface{}
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		assert.Equal(t, "ns1", input["namespace"])
+		if len(k.keys) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			writeJSON(w, map[string]interface{}{"code": 1008, "description": "Parameter was provided but invalid", "details": "two keys exist"})
+			return
+		}
+		if minutes, ok := input["existing_key_expiry_time_mins"]; ok {
+			k.expiry = append(k.expiry, int64(minutes.(float64)))
+		}
+		k.created++
+		key := map[string]string{
+			"secret_key":    fmt.Sprintf("key%d", k.created),
+			"key_timestamp": fmt.Sprintf("2017-01-01 00:00:0%d.000", k.created),
+		}
+		k.keys = append(k.keys, key)
+		writeJSON(w, key)
+	}
+	fake.handlers["POST /object/user-secret-keys/user1/deactivate"] = func(w http.ResponseWriter, r *http.Request) {
+		var input map[string]string
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&input))
+		var kept []map[string]string
+		for _, key := range k.keys {
+			if input["secret_key"] != "" && key["secret_key"] != input["secret_key"] {
+				kept = append(kept, key)
+			}
+		}
+		k.keys = kept
+	}
+}
+
+func (k *fakeKeys) names() []string {
+	var names []string
+	for _, key := range k.keys {
+		names = append(names, key["secret_key"])
+	}
+	return names
+}
+
+func TestMgmtRotateSecretKey(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	keys := &fakeKeys{}
+	keys.register(t, fake)
+	client := newClient(server.URL)
+
+	key, err := client.CreateSecretKey("user1", &mgmt.CreateSecretKeyInput{Namespace: "ns1"})
+	assert.Nil(t, err)
+	assert.Equal(t, "key1", key.SecretKey)
+
+	key, err = client.RotateSecretKey("user1", "ns1", 90*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, "key2", key.SecretKey)
+	assert.Equal(t, []string{"key1", "key2"}, keys.names())
+
+	key, err = client.RotateSecretKey("user1", "ns1", time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, "key3", key.SecretKey)
+	assert.Equal(t, []string{"key2", "key3"}, keys.names())
+	assert.Equal(t, []int64{2, 60}, keys.expiry)
+
+	assert.Nil(t, client.ExpireSecretKey("user1", "ns1", "key2"))
+	assert.Equal(t, []string{"key3"}, keys.names())
+	assert.Nil(t, client.ExpireAllSecretKeys("user1", "ns1"))
+	assert.Nil(t, keys.names())
+}
+
+func TestMgmtSecretKeyValidation(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	client := newClient(server.URL)
+
+	// an empty key would expire all the keys of the user
+	err := client.ExpireSecretKey("user1", "ns1", "")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	err = client.ExpireAllSecretKeys("", "ns1")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	_, err = client.RotateSecretKey("user1", "ns1", -time.Minute)
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	_, err = client.RotateSecretKey("", "ns1", time.Minute)
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	_, err = client.CreateSecretKey("", &mgmt.CreateSecretKeyInput{Namespace: "ns1"})
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	_, err = client.GetSecretKeys("", "ns1")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+
+	_, err = client.CreateObjectUser(nil)
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	_, err = client.CreateObjectUser(&mgmt.CreateObjectUserInput{User: "user1"})
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	err = client.DeleteObjectUser("", "ns1")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	_, err = client.GetObjectUserInfo("", "ns1")
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+
+	logins, _ := fake.sessions()
+	assert.Equal(t, 0, logins)
+}
+
+func TestMgmtNewCredentials(t *testing.T) {
+	fake, server := newFakeMgmt()
+	defer server.Close()
+	keys := &fakeKeys{}
+	keys.register(t, fake)
+	client := newClient(server.URL)
+
+	creds, err := client.NewCredentials("user1", "ns1")
+	assert.Nil(t, err)
+	value, err := creds.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, "user1", value.AccessKeyID)
+	assert.Equal(t, "key1", value.SecretAccessKey)
+}
@@ -0,0 +1,177 @@
+package mgmt
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ObjectUser identifies an object user of a namespace.
+type ObjectUser struct {
+	UserID    string `json:"userid"`
+	Namespace string `json:"namespace"`
+}
+
+// ObjectUserInfo describes an object user.
+type ObjectUserInfo struct {
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace"`
+	Locked    bool     `json:"locked"`
+	Created   string   `json:"created"`
+	Tags      []string `json:"tags"`
+}
+
+// SecretKeys holds the S3 secret keys of an object user. ECS keeps at most two
+// keys per user; an empty SecretKey2 means the second slot is unused.
+type SecretKeys struct {
+	SecretKey1          string `json:"secret_key_1"`
+	KeyTimestamp1       string `json:"key_timestamp_1"`
+	KeyExpiryTimestamp1 string `json:"key_expiry_timestamp_1"`
+	SecretKey2          string `json:"secret_key_2"`
+	KeyTimestamp2       string `json:"key_timestamp_2"`
+	KeyExpiryTimestamp2 string `json:"key_expiry_timestamp_2"`
+	Link                *Link  `json:"link,omitempty"`
+}
+
+// CreateObjectUserInput holds the settings of a new object user.
+type CreateObjectUserInput struct {
+	User      string   `json:"user"`
+	Namespace string   `json:"namespace"`
+	Tags      []string `json:"tags,omitempty"`
+}
+
+type objectUserRef struct {
+	User      string `json:"user"`
+	Namespace string `json:"namespace"`
+}
+
+type objectUserList struct {
+	Users      []*ObjectUser `json:"blobuser"`
+	NextMarker string        `json:"NextMarker"`
+}
+
+// namespaceQuery returns the query selecting namespace, if any.
+func namespaceQuery(namespace string) url.Values {
+	query := url.Values{}
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+	return query
+}
+
+// ListObjectUsers returns the object users of namespace, or of all
+// namespaces if namespace is empty.
+func (c *Client) ListObjectUsers(namespace string) ([]*ObjectUser, error) {
+	return c.ListObjectUsersWithContext(aws.BackgroundContext(), namespace)
+}
+
+// ListObjectUsersWithContext is the same as ListObjectUsers with the addition of
+// the ability to pass a context.
+func (c *Client) ListObjectUsersWithContext(ctx aws.Context, namespace string) ([]*ObjectUser, error) {
+	var all []*ObjectUser
+	query := namespaceQuery(namespace)
+	for {
+		var page objectUserList
+		if err := c.do(ctx, "GET", "/object/users", query, nil, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Users...)
+		if page.NextMarker == "" {
+			return all, nil
+		}
+		query.Set("marker", page.NextMarker)
+	}
+}
+
+// GetObjectUserInfo returns the details of an object user.
+func (c *Client) GetObjectUserInfo(uid, namespace string) (*ObjectUserInfo, error) {
+	return c.GetObjectUserInfoWithContext(aws.BackgroundContext(), uid, namespace)
+}
+
+// GetObjectUserInfoWithContext is the same as GetObjectUserInfo with the addition of
+// the ability to pass a context.
+func (c *Client) GetObjectUserInfoWithContext(ctx aws.Context, uid, namespace string) (*ObjectUserInfo, error) {
+	if err := validateParams("GetObjectUserInfo", param{"UserID", uid}); err != nil {
+		return nil, err
+	}
+	info := &ObjectUserInfo{}
+	if err := c.do(ctx, "GET", "/object/users/"+url.PathEscape(uid)+"/info", namespaceQuery(namespace), nil, info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// GetSecretKeys returns the S3 secret keys of an object user.
+func (c *Client) GetSecretKeys(uid, namespace string) (*SecretKeys, error) {
+	return c.GetSecretKeysWithContext(aws.BackgroundContext(), uid, namespace)
+}
+
+// GetSecretKeysWithContext is the same as GetSecretKeys with the addition of
+// the ability to pass a context.
+func (c *Client) GetSecretKeysWithContext(ctx aws.Context, uid, namespace string) (*SecretKeys, error) {
+	if err := validateParams("GetSecretKeys", param{"UserID", uid}); err != nil {
+		return nil, err
+	}
+	keys := &SecretKeys{}
+	path := "/object/user-secret-keys/" + url.PathEscape(uid)
+	if namespace != "" {
+		path += "/" + url.PathEscape(namespace)
+	}
+	if err := c.do(ctx, "GET", path, nil, nil, keys); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// CreateObjectUser creates an object user. The user has no secret key until
+// one is created with CreateSecretKey.
+func (c *Client) CreateObjectUser(input *CreateObjectUserInput) (*ObjectUser, error) {
+	return c.CreateObjectUserWithContext(aws.BackgroundContext(), input)
+}
+
+// CreateObjectUserWithContext is the same as CreateObjectUser with the addition of
+// the ability to pass a context.
+func (c *Client) CreateObjectUserWithContext(ctx aws.Context, input *CreateObjectUserInput) (*ObjectUser, error) {
+	if input == nil {
+		invalidParams := request.ErrInvalidParams{Context: "CreateObjectUser"}
+		invalidParams.Add(request.NewErrParamRequired("CreateObjectUserInput"))
+		return nil, invalidParams
+	}
+	if err := validateParams("CreateObjectUser", param{"User", input.User}, param{"Namespace", input.Namespace}); err != nil {
+		return nil, err
+	}
+	if err := c.do(ctx, "POST", "/object/users", nil, input, nil); err != nil {
+		return nil, err
+	}
+	return &ObjectUser{UserID: input.User, Namespace: input.Namespace}, nil
+}
+
+// DeleteObjectUser deletes an object user and its secret keys.
+func (c *Client) DeleteObjectUser(uid, namespace string) error {
+	return c.DeleteObjectUserWithContext(aws.BackgroundContext(), uid, namespace)
+}
+
+// DeleteObjectUserWithContext is the same as DeleteObjectUser with the addition of
+// the ability to pass a context.
+func (c *Client) DeleteObjectUserWithContext(ctx aws.Context, uid, namespace string) error {
+	if err := validateParams("DeleteObjectUser", param{"UserID", uid}, param{"Namespace", namespace}); err != nil {
+		return err
+	}
+	return c.do(ctx, "POST", "/object/users/deactivate", nil, &objectUserRef{User: uid, Namespace: namespace}, nil)
+}
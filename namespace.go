@@ -0,0 +1,67 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// NamespaceHeader is the header selecting the namespace of a request.
+const NamespaceHeader = "x-emc-namespace"
+
+// WithNamespace returns a request option sending the request to namespace.
+// It can be passed to any WithContext operation, including those of the
+// embedded s3.S3, or to NewWithOptions to apply to every request of the
+// client. A namespace set on the request itself takes precedence over the
+// client's, and the NameSpace field of CreateBucketInput over both.
+func WithNamespace(namespace string) request.Option {
+	return requestOption(func(r *request.Request) {
+		if input, ok := r.Params.(*CreateBucketInput); !ok || input.NameSpace == nil {
+			r.HTTPRequest.Header.Set(NamespaceHeader, namespace)
+		}
+	})
+}
+
+// WithNamespaceHost returns a request option addressing the namespace of the
+// request by hostname, e.g. bucket.namespace.ecs.example.com for the endpoint
+// ecs.example.com, as required by DNS-style ECS deployments. The namespace is
+// the one set by WithNamespace or CreateBucketInput; requests without one are
+// not changed.
+func WithNamespaceHost() request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.RemoveByName(namespaceHostHandler.Name)
+		r.Handlers.Build.PushFrontNamed(namespaceHostHandler)
+	}
+}
+
+var namespaceHostHandler = request.NamedHandler{Name: "ecs.NamespaceHostHandler", Fn: namespaceHost}
+
+// namespaceHost prefixes the endpoint host with the namespace. It runs before
+// the s3 handler that moves the bucket into the host.
+func namespaceHost(r *request.Request) {
+	if namespace := requestNamespace(r); namespace != "" {
+		r.HTTPRequest.URL.Host = namespace + "." + r.HTTPRequest.URL.Host
+	}
+}
+
+// requestNamespace returns the namespace already chosen for r, if any.
+func requestNamespace(r *request.Request) string {
+	if input, ok := r.Params.(*CreateBucketInput); ok && input.NameSpace != nil {
+		return aws.StringValue(input.NameSpace)
+	}
+	return r.HTTPRequest.Header.Get(NamespaceHeader)
+}
@@ -0,0 +1,65 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithNamespace(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	client := ecs.New(s3.New(newLocalSession(server.URL)), ecs.WithNamespace("ns1"))
+
+	_, err := client.ListObjects(&s3.ListObjectsInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	_, err = client.ListObjectsWithContext(aws.BackgroundContext(), &s3.ListObjectsInput{Bucket: aws.String("bucket")}, ecs.WithNamespace("ns2"))
+	assert.Nil(t, err)
+	_, err = client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("bucket"), NameSpace: aws.String("ns3")})
+	assert.Nil(t, err)
+	_, err = client.ListBucketMetadataSearch(&ecs.ListBucketMetadataSearchInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	_, err = newLocalClient(server.URL).ListObjects(&s3.ListObjectsInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+
+	var namespaces [][]string
+	for _, r := range requests {
+		namespaces = append(namespaces, r.Header[http.CanonicalHeaderKey(ecs.NamespaceHeader)])
+	}
+	assert.Equal(t, [][]string{{"ns1"}, {"ns2"}, {"ns3"}, {"ns1"}, nil}, namespaces)
+}
+
+func TestWithNamespaceHost(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	sess, port := newHostSession(server)
+	client := ecs.NewWithOptions(sess, ecs.WithNamespace("ns1"), ecs.WithNamespaceHost())
+
+	_, err := client.ListObjects(&s3.ListObjectsInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	_, err = client.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("bucket"), NameSpace: aws.String("ns2")})
+	assert.Nil(t, err)
+	assert.Equal(t, "bucket.ns1.ecs.example.com:"+port, requests[0].Host)
+	assert.Equal(t, "bucket.ns2.ecs.example.com:"+port, requests[1].Host)
+}
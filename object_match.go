@@ -0,0 +1,192 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+)
+
+const (
+	// MetadataTypeSystem is a MetadataType enum value
+	MetadataTypeSystem = "SYSMD"
+
+	// MetadataTypeUser is a MetadataType enum value
+	MetadataTypeUser = "USERMD"
+)
+
+// DatetimeFormat is the layout ECS uses for Datetime metadata values.
+const DatetimeFormat = "2006-01-02T15:04:05Z"
+
+// systemMetadataFields maps indexable system keys to the names ECS uses for
+// them in the SYSMD metadata of a query result.
+var systemMetadataFields = map[string]string{
+	"ObjectName":   "ObjectName",
+	"Owner":        "owner",
+	"Size":         "size",
+	"CreateTime":   "createtime",
+	"LastModified": "mtime",
+	"ContentType":  "ctype",
+	"Expiration":   "expiration",
+	"Expires":      "expires",
+	"Retention":    "retention",
+}
+
+// SystemMetadata returns the system metadata of the match.
+func (s *EcsObjectMatch) SystemMetadata() map[string]string {
+	return s.metadata(MetadataTypeSystem)
+}
+
+// UserMetadata returns the user metadata of the match, keyed by the full
+// x-amz-meta- key name.
+func (s *EcsObjectMatch) UserMetadata() map[string]string {
+	return s.metadata(MetadataTypeUser)
+}
+
+func (s *EcsObjectMatch) metadata(t string) map[string]string {
+	md := map[string]string{}
+	for _, q := range s.QueryMetadata {
+		if q == nil || !strings.EqualFold(aws.StringValue(q.MetadataType), t) {
+			continue
+		}
+		for k, v := range q.MetadataMap {
+			md[k] = aws.StringValue(v)
+		}
+	}
+	return md
+}
+
+// systemValue returns the raw value of the system key name.
+func (s *EcsObjectMatch) systemValue(name string) (string, bool) {
+	if name == "ObjectName" && s.ObjectName != nil {
+		return *s.ObjectName, true
+	}
+	field, ok := systemMetadataFields[name]
+	if !ok {
+		field = name
+	}
+	for k, v := range s.SystemMetadata() {
+		if strings.EqualFold(k, field) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// userValue returns the raw value of the user key name.
+func (s *EcsObjectMatch) userValue(name string) (string, bool) {
+	for k, v := range s.UserMetadata() {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Size returns the size of the object in bytes.
+func (s *EcsObjectMatch) Size() (int64, bool) {
+	v, ok := s.systemValue("Size")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	return n, err == nil
+}
+
+// LastModified returns the last modification time of the object.
+func (s *EcsObjectMatch) LastModified() (time.Time, bool) {
+	return s.timeValue("LastModified")
+}
+
+// CreateTime returns the creation time of the object.
+func (s *EcsObjectMatch) CreateTime() (time.Time, bool) {
+	return s.timeValue("CreateTime")
+}
+
+func (s *EcsObjectMatch) timeValue(name string) (time.Time, bool) {
+	v, ok := s.systemValue(name)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := parseDatetime(v)
+	return t, err == nil
+}
+
+// ContentType returns the content type of the object. It is only present
+// when the query requested the ContentType attribute.
+func (s *EcsObjectMatch) ContentType() (string, bool) {
+	return s.systemValue("ContentType")
+}
+
+// Owner returns the owner of the object.
+func (s *EcsObjectMatch) Owner() (string, bool) {
+	return s.systemValue("Owner")
+}
+
+// Value returns the raw value of a system or user metadata key.
+func (s *EcsObjectMatch) Value(name string) (string, bool) {
+	if IsUserMetadataKey(name) {
+		return s.userValue(name)
+	}
+	return s.systemValue(name)
+}
+
+// IndexedValue returns the value of the indexed key k parsed according to
+// k.Datatype, as listed by ListBucketMetadataSearch. See ParseMetadataValue
+// for the types returned. It returns an error if k or its name is nil.
+func (s *EcsObjectMatch) IndexedValue(k *EcsIndexableKey) (interface{}, bool, error) {
+	if k == nil || k.Name == nil {
+		return nil, false, fmt.Errorf("indexed key has no name")
+	}
+	v, ok := s.Value(*k.Name)
+	if !ok {
+		return nil, false, nil
+	}
+	parsed, err := ParseMetadataValue(aws.StringValue(k.Datatype), v)
+	return parsed, true, err
+}
+
+// ParseMetadataValue parses v as the given Datatype. It returns a string for
+// String, int64 for Integer, float64 for Decimal and time.Time for Datetime.
+func ParseMetadataValue(datatype string, v string) (interface{}, error) {
+	switch NormalizeDatatype(datatype) {
+	case DatatypeString:
+		return v, nil
+	case DatatypeInteger:
+		return strconv.ParseInt(v, 10, 64)
+	case DatatypeDecimal:
+		return strconv.ParseFloat(v, 64)
+	case DatatypeDatetime:
+		return parseDatetime(v)
+	}
+	return nil, fmt.Errorf("unknown datatype %q", datatype)
+}
+
+// parseDatetime parses a Datetime value, which ECS reports either in
+// DatetimeFormat or as milliseconds since the epoch.
+func parseDatetime(v string) (time.Time, error) {
+	if ms, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(0, ms*int64(time.Millisecond)).UTC(), nil
+	}
+	if t, err := time.Parse(DatetimeFormat, v); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,85 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEcsObjectMatchMetadata(t *testing.T) {
+	match := (&ecs.EcsObjectMatch{}).
+		SetObjectName("key").
+		SetQueryMetadata([]*ecs.EcsQueryMetadata{
+			(&ecs.EcsQueryMetadata{}).SetMetadataType(ecs.MetadataTypeSystem).SetMetadataMap(map[string]*string{
+				"size":  aws.String("1024"),
+				"mtime": aws.String("1496313000000"),
+				"ctype": aws.String("text/plain"),
+			}),
+			(&ecs.EcsQueryMetadata{}).SetMetadataType(ecs.MetadataTypeUser).SetMetadataMap(map[string]*string{
+				"x-amz-meta-int": aws.String("42"),
+				"x-amz-meta-dt":  aws.String("2017-06-01T10:30:00Z"),
+			}),
+		})
+
+	size, ok := match.Size()
+	assert.True(t, ok)
+	assert.Equal(t, int64(1024), size)
+
+	modified, ok := match.LastModified()
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2017, 6, 1, 10, 30, 0, 0, time.UTC), modified)
+
+	ctype, ok := match.ContentType()
+	assert.True(t, ok)
+	assert.Equal(t, "text/plain", ctype)
+
+	_, ok = match.CreateTime()
+	assert.False(t, ok)
+
+	assert.Equal(t, map[string]string{"x-amz-meta-int": "42", "x-amz-meta-dt": "2017-06-01T10:30:00Z"}, match.UserMetadata())
+
+	v, ok, err := match.IndexedValue(&ecs.EcsIndexableKey{Name: aws.String("x-amz-meta-INT"), Datatype: aws.String("integer")})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(42), v)
+
+	v, ok, err = match.IndexedValue(&ecs.EcsIndexableKey{Name: aws.String("x-amz-meta-DT"), Datatype: aws.String("datetime")})
+	assert.True(t, ok)
+	assert.Nil(t, err)
+	assert.Equal(t, modified, v)
+
+	v, ok, err = match.IndexedValue(&ecs.EcsIndexableKey{Name: aws.String("ObjectName"), Datatype: aws.String("string")})
+	assert.True(t, ok)
+	assert.Equal(t, "key", v)
+
+	_, ok, err = match.IndexedValue(&ecs.EcsIndexableKey{Name: aws.String("x-amz-meta-DT"), Datatype: aws.String("decimal")})
+	assert.True(t, ok)
+	assert.NotNil(t, err)
+
+	_, ok, _ = match.IndexedValue(&ecs.EcsIndexableKey{Name: aws.String("x-amz-meta-none"), Datatype: aws.String("string")})
+	assert.False(t, ok)
+
+	for _, k := range []*ecs.EcsIndexableKey{nil, {Datatype: aws.String("string")}} {
+		_, ok, err = match.IndexedValue(k)
+		assert.False(t, ok)
+		assert.NotNil(t, err)
+	}
+}
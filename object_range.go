@@ -0,0 +1,176 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// AppendRange is the Range header value that appends the body of a
+// PutObjectExtension request to the end of the object.
+const AppendRange = "bytes=-1-"
+
+// UpdateRange returns the Range header value that replaces length bytes of
+// an object starting at offset.
+func UpdateRange(offset, length int64) string {
+	return fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+}
+
+// OverwriteRange returns the Range header value that writes the body of a
+// PutObjectExtension request at offset, extending the object if needed.
+func OverwriteRange(offset int64) string {
+	return fmt.Sprintf("bytes=%d-", offset)
+}
+
+// UpdateObjectRange replaces the bytes of an existing object starting at
+// offset with body. The range must lie within the object.
+func (c *S3) UpdateObjectRange(bucket, key string, offset int64, body io.ReadSeeker) (*PutObjectOutput, error) {
+	return c.UpdateObjectRangeWithContext(aws.BackgroundContext(), bucket, key, offset, body)
+}
+
+// UpdateObjectRangeWithContext is the same as UpdateObjectRange with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) UpdateObjectRangeWithContext(ctx aws.Context, bucket, key string, offset int64, body io.ReadSeeker, opts ...request.Option) (*PutObjectOutput, error) {
+	invalidParams := request.ErrInvalidParams{Context: "UpdateObjectRange"}
+	if offset < 0 {
+		invalidParams.Add(request.NewErrParamMinValue("Offset", 0))
+	}
+	length, err := bodyLength(body)
+	if err != nil {
+		return nil, err
+	}
+	if length < 1 {
+		invalidParams.Add(request.NewErrParamMinLen("Body", 1))
+	}
+	if invalidParams.Len() > 0 {
+		return nil, invalidParams
+	}
+
+	return c.putObjectRange(ctx, bucket, key, UpdateRange(offset, length), body, opts...)
+}
+
+// OverwriteObjectRange writes body into an existing object starting at offset,
+// extending the object when the write goes past its end.
+func (c *S3) OverwriteObjectRange(bucket, key string, offset int64, body io.ReadSeeker) (*PutObjectOutput, error) {
+	return c.OverwriteObjectRangeWithContext(aws.BackgroundContext(), bucket, key, offset, body)
+}
+
+// OverwriteObjectRangeWithContext is the same as OverwriteObjectRange with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) OverwriteObjectRangeWithContext(ctx aws.Context, bucket, key string, offset int64, body io.ReadSeeker, opts ...request.Option) (*PutObjectOutput, error) {
+	invalidParams := request.ErrInvalidParams{Context: "OverwriteObjectRange"}
+	if offset < 0 {
+		invalidParams.Add(request.NewErrParamMinValue("Offset", 0))
+	}
+	length, err := bodyLength(body)
+	if err != nil {
+		return nil, err
+	}
+	if length < 1 {
+		invalidParams.Add(request.NewErrParamMinLen("Body", 1))
+	}
+	if invalidParams.Len() > 0 {
+		return nil, invalidParams
+	}
+
+	return c.putObjectRange(ctx, bucket, key, OverwriteRange(offset), body, opts...)
+}
+
+// AppendObject appends body to the end of an existing object. The offset the
+// data was written at is returned in AppendOffset.
+func (c *S3) AppendObject(bucket, key string, body io.ReadSeeker) (*PutObjectOutput, error) {
+	return c.AppendObjectWithContext(aws.BackgroundContext(), bucket, key, body)
+}
+
+// AppendObjectWithContext is the same as AppendObject with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) AppendObjectWithContext(ctx aws.Context, bucket, key string, body io.ReadSeeker, opts ...request.Option) (*PutObjectOutput, error) {
+	if body == nil {
+		invalidParams := request.ErrInvalidParams{Context: "AppendObject"}
+		invalidParams.Add(request.NewErrParamRequired("Body"))
+		return nil, invalidParams
+	}
+
+	return c.putObjectRange(ctx, bucket, key, AppendRange, body, opts...)
+}
+
+func (c *S3) putObjectRange(ctx aws.Context, bucket, key, byteRange string, body io.ReadSeeker, opts ...request.Option) (*PutObjectOutput, error) {
+	body, err := bodyRemainder(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.PutObjectExtensionWithContext(ctx, &PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   body,
+		Range:  aws.String(byteRange),
+	}, opts...)
+}
+
+// bodyLength returns the number of bytes of body after its current offset,
+// which is what a request sends.
+func bodyLength(body io.ReadSeeker) (int64, error) {
+	if body == nil {
+		return 0, nil
+	}
+	cur, err := body.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return 0, err
+	}
+	end, err := body.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if _, err = body.Seek(cur, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return end - cur, nil
+}
+
+// bodyRemainder returns body as a stream starting at its current offset. The
+// SDK seeks the body of a request back to its start before sending it, which
+// would send more than the range covers.
+func bodyRemainder(body io.ReadSeeker) (io.ReadSeeker, error) {
+	if body == nil {
+		return nil, nil
+	}
+	cur, err := body.Seek(0, io.SeekCurrent)
+	if err != nil || cur == 0 {
+		return body, err
+	}
+	return &offsetReadSeeker{ReadSeeker: body, start: cur}, nil
+}
+
+// offsetReadSeeker is the part of a ReadSeeker after start.
+type offsetReadSeeker struct {
+	io.ReadSeeker
+	start int64
+}
+
+func (r *offsetReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekStart {
+		if offset < 0 {
+			return 0, fmt.Errorf("seek to negative offset %d", offset)
+		}
+		offset += r.start
+	}
+	n, err := r.ReadSeeker.Seek(offset, whence)
+	return n - r.start, err
+}
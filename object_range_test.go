@@ -0,0 +1,89 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectRange(t *testing.T) {
+	var ranges, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		ranges = append(ranges, r.Header.Get("Range"))
+		bodies = append(bodies, string(b))
+		w.Header().Set("x-emc-previous-object-size", "4")
+		if r.Header.Get("Range") == "bytes=-1-" {
+			w.Header().Set("x-emc-append-offset", "4")
+		}
+	}))
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	out, err := client.UpdateObjectRange("bucket", "key", 1, strings.NewReader("567"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), *out.PreviousObjectSize)
+	assert.Nil(t, out.AppendOffset)
+
+	_, err = client.OverwriteObjectRange("bucket", "key", 2, strings.NewReader("89"))
+	assert.Nil(t, err)
+
+	out, err = client.AppendObject("bucket", "key", strings.NewReader("0"))
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), *out.AppendOffset)
+
+	assert.Equal(t, []string{"bytes=1-3", "bytes=2-", "bytes=-1-"}, ranges)
+	assert.Equal(t, []string{"567", "89", "0"}, bodies)
+
+	_, err = client.UpdateObjectRange("bucket", "key", -1, strings.NewReader(""))
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+	assert.Equal(t, 2, err.(request.ErrInvalidParams).Len())
+
+	_, err = client.OverwriteObjectRange("bucket", "key", 2, strings.NewReader(""))
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+
+	_, err = client.AppendObject("bucket", "key", nil)
+	assert.NotNil(t, err)
+	assert.Len(t, ranges, 3)
+}
+
+func TestObjectRangeSeekedBody(t *testing.T) {
+	var ranges, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		ranges = append(ranges, r.Header.Get("Range"))
+		bodies = append(bodies, string(b))
+	}))
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	// Only the data after the offset of the body is sent.
+	body := strings.NewReader("0123456")
+	body.Seek(4, io.SeekStart)
+	_, err := client.UpdateObjectRange("bucket", "key", 10, body)
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"bytes=10-12"}, ranges)
+	assert.Equal(t, []string{"456"}, bodies)
+}
@@ -0,0 +1,238 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// ByteRange is a range of bytes of an object.
+type ByteRange struct {
+	Offset int64
+	Length int64
+}
+
+// String returns the range in Range header syntax, without the unit.
+func (r ByteRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Offset, r.Offset+r.Length-1)
+}
+
+// ObjectPart is one range of an object returned by GetObjectRanges.
+type ObjectPart struct {
+	// Offset of the part within the object.
+	Offset int64
+	// Length of the part in bytes, -1 if the server did not report it.
+	Length int64
+	// Size of the whole object, -1 if the server did not report it.
+	ObjectSize int64
+	// Body of the part. It is only valid until the next call to Next.
+	Body io.Reader
+}
+
+// GetObjectRangesOutput is the response of GetObjectRanges. The parts are read
+// in order with Next; the embedded GetObjectOutput carries the response
+// headers and must not be read from directly.
+//
+//	out, err := client.GetObjectRanges(input, ranges)
+//	// check err
+//	defer out.Close()
+//	for out.Next() {
+//	    part := out.Part()
+//	    // read part.Body
+//	}
+//	if err := out.Err(); err != nil {
+//	    // handle error
+//	}
+type GetObjectRangesOutput struct {
+	*GetObjectOutput
+
+	mr      *multipart.Reader
+	pending *ObjectPart
+	part    *ObjectPart
+	err     error
+}
+
+// Next advances to the next part. It returns false when all parts were read
+// or an error occurred.
+func (o *GetObjectRangesOutput) Next() bool {
+	if o.err != nil {
+		return false
+	}
+	if o.part != nil {
+		// Drain the previous part so the multipart reader can move on.
+		io.Copy(ioutil.Discard, o.part.Body)
+		o.part = nil
+	}
+	if o.pending != nil {
+		o.part, o.pending = o.pending, nil
+		return true
+	}
+	if o.mr == nil {
+		return false
+	}
+
+	p, err := o.mr.NextPart()
+	if err == io.EOF {
+		return false
+	}
+	if err != nil {
+		o.err = err
+		return false
+	}
+	part, err := parseContentRange(p.Header.Get("Content-Range"))
+	if err != nil {
+		o.err = err
+		return false
+	}
+	part.Body = io.LimitReader(p, part.Length)
+	o.part = part
+	return true
+}
+
+// Part returns the current part.
+func (o *GetObjectRangesOutput) Part() *ObjectPart {
+	return o.part
+}
+
+// Err returns the error that stopped reading parts, if any.
+func (o *GetObjectRangesOutput) Err() error {
+	return o.err
+}
+
+// Close closes the response body.
+func (o *GetObjectRangesOutput) Close() error {
+	if o.Body == nil {
+		return nil
+	}
+	return o.Body.Close()
+}
+
+// GetObjectRanges reads several byte ranges of an object with a single
+// request. If the server answers with a single range or with the whole
+// object, the response is returned as a single part.
+func (c *S3) GetObjectRanges(input *s3.GetObjectInput, ranges []ByteRange) (*GetObjectRangesOutput, error) {
+	return c.GetObjectRangesWithContext(aws.BackgroundContext(), input, ranges)
+}
+
+// GetObjectRangesWithContext is the same as GetObjectRanges with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) GetObjectRangesWithContext(ctx aws.Context, input *s3.GetObjectInput, ranges []ByteRange, opts ...request.Option) (*GetObjectRangesOutput, error) {
+	if err := ValidateByteRanges(ranges); err != nil {
+		return nil, err
+	}
+
+	specs := make([]string, len(ranges))
+	for i, r := range ranges {
+		specs[i] = r.String()
+	}
+	var in s3.GetObjectInput
+	if input != nil {
+		in = *input
+	}
+	in.Range = aws.String("bytes=" + strings.Join(specs, ","))
+
+	out, err := c.GetObjectExtensionWithContext(ctx, &in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewGetObjectRangesOutput(out)
+}
+
+// ValidateByteRanges checks the ranges of a GetObjectRanges request: there
+// must be at least one, with an Offset of at least 0 and a Length of at
+// least 1. It is used by implementations of ecsiface.ECSAPI.
+func ValidateByteRanges(ranges []ByteRange) error {
+	invalidParams := request.ErrInvalidParams{Context: "GetObjectRanges"}
+	if len(ranges) == 0 {
+		invalidParams.Add(request.NewErrParamMinLen("Ranges", 1))
+	}
+	for i, r := range ranges {
+		if r.Offset < 0 {
+			invalidParams.Add(request.NewErrParamMinValue(fmt.Sprintf("Ranges[%d].Offset", i), 0))
+		}
+		if r.Length < 1 {
+			invalidParams.Add(request.NewErrParamMinValue(fmt.Sprintf("Ranges[%d].Length", i), 1))
+		}
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// NewGetObjectRangesOutput returns the parts of out, the response to a
+// GetObject request for one or more byte ranges. It is used by
+// implementations of ecsiface.ECSAPI.
+func NewGetObjectRangesOutput(out *GetObjectOutput) (*GetObjectRangesOutput, error) {
+	var err error
+	o := &GetObjectRangesOutput{GetObjectOutput: out}
+
+	mediaType, params, _ := mime.ParseMediaType(aws.StringValue(out.ContentType))
+	switch {
+	case mediaType == "multipart/byteranges":
+		o.mr = multipart.NewReader(out.Body, params["boundary"])
+	case out.ContentRange != nil:
+		if o.pending, err = parseContentRange(*out.ContentRange); err != nil {
+			out.Body.Close()
+			return nil, err
+		}
+		o.pending.Body = out.Body
+	default:
+		// The whole object, of unknown size if the response has no
+		// Content-Length, e.g. when it is chunked.
+		size := int64(-1)
+		if out.ContentLength != nil {
+			size = *out.ContentLength
+		}
+		o.pending = &ObjectPart{Length: size, ObjectSize: size, Body: out.Body}
+	}
+	return o, nil
+}
+
+// parseContentRange parses a Content-Range header such as "bytes 0-9/100".
+func parseContentRange(v string) (*ObjectPart, error) {
+	spec := strings.TrimPrefix(strings.TrimSpace(v), "bytes ")
+	slash := strings.Index(spec, "/")
+	dash := strings.Index(spec, "-")
+	if slash < 0 || dash < 0 || dash > slash {
+		return nil, fmt.Errorf("invalid Content-Range %q", v)
+	}
+	first, err := strconv.ParseInt(spec[:dash], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Range %q", v)
+	}
+	last, err := strconv.ParseInt(spec[dash+1:slash], 10, 64)
+	if err != nil || last < first {
+		return nil, fmt.Errorf("invalid Content-Range %q", v)
+	}
+	size := int64(-1)
+	if total := spec[slash+1:]; total != "*" {
+		if size, err = strconv.ParseInt(total, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid Content-Range %q", v)
+		}
+	}
+	return &ObjectPart{Offset: first, Length: last - first + 1, ObjectSize: size}, nil
+}
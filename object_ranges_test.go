@@ -0,0 +1,111 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+const rangesContent = "0123456789abcdefghij"
+
+func TestGetObjectRanges(t *testing.T) {
+	var mode string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch mode {
+		case "multipart":
+			assert.Equal(t, "bytes=0-1,10-12", r.Header.Get("Range"))
+			mw := multipart.NewWriter(w)
+			w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+			w.WriteHeader(http.StatusPartialContent)
+			for _, rg := range [][2]int{{0, 1}, {10, 12}} {
+				pw, _ := mw.CreatePart(textproto.MIMEHeader{
+					"Content-Type":  {"text/plain"},
+					"Content-Range": {fmt.Sprintf("bytes %d-%d/%d", rg[0], rg[1], len(rangesContent))},
+				})
+				pw.Write([]byte(rangesContent[rg[0] : rg[1]+1]))
+			}
+			mw.Close()
+		case "single":
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 5-7/%d", len(rangesContent)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write([]byte(rangesContent[5:8]))
+		case "chunked":
+			w.(http.Flusher).Flush()
+			w.Write([]byte(rangesContent))
+		default:
+			w.Write([]byte(rangesContent))
+		}
+	}))
+	defer server.Close()
+	client := newLocalClient(server.URL)
+	input := &s3.GetObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")}
+
+	read := func(ranges ...ecs.ByteRange) ([]ecs.ObjectPart, []string) {
+		out, err := client.GetObjectRanges(input, ranges)
+		assert.Nil(t, err)
+		defer out.Close()
+
+		var parts []ecs.ObjectPart
+		var bodies []string
+		for out.Next() {
+			b, _ := ioutil.ReadAll(out.Part().Body)
+			parts = append(parts, *out.Part())
+			bodies = append(bodies, string(b))
+		}
+		assert.Nil(t, out.Err())
+		return parts, bodies
+	}
+
+	mode = "multipart"
+	parts, bodies := read(ecs.ByteRange{Offset: 0, Length: 2}, ecs.ByteRange{Offset: 10, Length: 3})
+	assert.Equal(t, []string{"01", "abc"}, bodies)
+	assert.Equal(t, int64(10), parts[1].Offset)
+	assert.Equal(t, int64(3), parts[1].Length)
+	assert.Equal(t, int64(20), parts[1].ObjectSize)
+	assert.Nil(t, input.Range)
+
+	mode = "single"
+	parts, bodies = read(ecs.ByteRange{Offset: 5, Length: 3})
+	assert.Equal(t, []string{"567"}, bodies)
+	assert.Equal(t, int64(5), parts[0].Offset)
+
+	mode = "full"
+	parts, bodies = read(ecs.ByteRange{Offset: 5, Length: 3}, ecs.ByteRange{Offset: 9, Length: 1})
+	assert.Equal(t, []string{rangesContent}, bodies)
+	assert.Equal(t, int64(20), parts[0].Length)
+
+	mode = "chunked"
+	parts, bodies = read(ecs.ByteRange{Offset: 5, Length: 3}, ecs.ByteRange{Offset: 9, Length: 1})
+	assert.Equal(t, []string{rangesContent}, bodies)
+	assert.Equal(t, int64(-1), parts[0].Length)
+	assert.Equal(t, int64(-1), parts[0].ObjectSize)
+
+	_, err := client.GetObjectRanges(input, nil)
+	assert.NotNil(t, err)
+	_, err = client.GetObjectRanges(input, []ecs.ByteRange{{Offset: -1, Length: 0}})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,95 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// NewWithOptions returns an ECS client with its own s3.S3 created from p.
+// The request options opts apply to every request of the client, including
+// those made through the embedded s3.S3, so two clients in one process can
+// behave differently:
+//
+//	client := ecs.NewWithOptions(sess,
+//		ecs.WithNamespace("ns1"),
+//		ecs.WithDefaultVPool("rg1"),
+//		ecs.WithContinueThreshold(8*1024*1024),
+//		ecs.WithPathStyle(),
+//	)
+//
+// The options are applied before the request is validated. Options passed to
+// a single operation take precedence over the client's.
+func NewWithOptions(p client.ConfigProvider, opts ...request.Option) *S3 {
+	return New(s3.New(p), opts...)
+}
+
+const clientOptionsHandlerName = "ecs.ClientOptionsHandler"
+
+// clientOptionsHandler applies opts to every request before it is validated.
+func clientOptionsHandler(opts []request.Option) request.NamedHandler {
+	return request.NamedHandler{Name: clientOptionsHandlerName, Fn: func(r *request.Request) {
+		r.ApplyOptions(opts...)
+	}}
+}
+
+// requestOption returns a request option applying fn when it is applied and
+// again after the client options, so that an option passed to an operation
+// overrides the same option of the client. fn must be idempotent.
+func requestOption(fn func(*request.Request)) request.Option {
+	return func(r *request.Request) {
+		fn(r)
+		r.Handlers.Validate.PushBack(fn)
+	}
+}
+
+// WithPathStyle returns a request option addressing the bucket in the path
+// rather than in the hostname.
+func WithPathStyle() request.Option {
+	return requestOption(func(r *request.Request) {
+		r.Config.S3ForcePathStyle = aws.Bool(true)
+	})
+}
+
+// VPoolHeader is the header selecting the replication group of a new bucket.
+const VPoolHeader = "x-emc-vpool"
+
+// WithDefaultVPool returns a request option creating buckets in the
+// replication group vpool unless CreateBucketInput sets VPool. It applies to
+// CreateBucketExtension and to the CreateBucket of the embedded s3.S3.
+func WithDefaultVPool(vpool string) request.Option {
+	return requestOption(func(r *request.Request) {
+		if r.Operation.Name != opCreateBucket {
+			return
+		}
+		if input, ok := r.Params.(*CreateBucketInput); !ok || input.VPool == nil {
+			r.HTTPRequest.Header.Set(VPoolHeader, vpool)
+		}
+	})
+}
+
+// WithHandlers returns a request option calling fn with the handlers of the
+// request, to add custom handlers per client or per operation. As client
+// options are applied while the request is validated, Validate handlers
+// added by a client option do not run.
+func WithHandlers(fn func(*request.Handlers)) request.Option {
+	return func(r *request.Request) {
+		fn(&r.Handlers)
+	}
+}
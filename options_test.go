@@ -0,0 +1,136 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordedRequest is what a recordingServer saw of a request.
+type recordedRequest struct {
+	Host   string
+	Path   string
+	Header http.Header
+}
+
+// recordingServer records every request and answers with an empty bucket
+// listing.
+func recordingServer(requests *[]recordedRequest) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, recordedRequest{Host: r.Host, Path: r.URL.Path, Header: r.Header})
+		if r.Method == "GET" {
+			w.Write([]byte(`<ListBucketResult><Name>bucket</Name></ListBucketResult>`))
+		}
+	}))
+}
+
+// newHostSession returns a session for the endpoint ecs.example.com whose
+// host names, including virtual-hosted buckets, all resolve to server.
+func newHostSession(server *httptest.Server) (*session.Session, string) {
+	u, _ := url.Parse(server.URL)
+	_, port, _ := net.SplitHostPort(u.Host)
+	transport := &http.Transport{Dial: func(network, addr string) (net.Conn, error) {
+		return net.Dial(network, u.Host)
+	}}
+	sess := session.Must(session.NewSession(&aws.Config{
+		Credentials: credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:    aws.String("http://ecs.example.com:" + port),
+		Region:      aws.String("us-east-1"),
+		MaxRetries:  aws.Int(0),
+		HTTPClient:  &http.Client{Transport: transport},
+	}))
+	return sess, port
+}
+
+func TestNewWithOptions(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	sess, port := newHostSession(server)
+	a := ecs.NewWithOptions(sess, ecs.WithNamespace("a"), ecs.WithDefaultVPool("rg1"), ecs.WithPathStyle())
+	b := ecs.NewWithOptions(sess, ecs.WithNamespace("b"))
+
+	_, err := a.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	_, err = a.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("bucket"), VPool: aws.String("rg2")})
+	assert.Nil(t, err)
+	_, err = b.ListObjects(&s3.ListObjectsInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	_, err = a.ListObjectsWithContext(aws.BackgroundContext(), &s3.ListObjectsInput{Bucket: aws.String("bucket")}, ecs.WithNamespace("c"))
+	assert.Nil(t, err)
+
+	assert.Equal(t, 4, len(requests))
+	assert.Equal(t, "ecs.example.com:"+port, requests[0].Host)
+	assert.Equal(t, "/bucket", requests[0].Path)
+	assert.Equal(t, []string{"a"}, requests[0].Header[http.CanonicalHeaderKey(ecs.NamespaceHeader)])
+	assert.Equal(t, []string{"rg1"}, requests[0].Header[http.CanonicalHeaderKey(ecs.VPoolHeader)])
+	assert.Equal(t, []string{"rg2"}, requests[1].Header[http.CanonicalHeaderKey(ecs.VPoolHeader)])
+	assert.Equal(t, "bucket.ecs.example.com:"+port, requests[2].Host)
+	assert.Equal(t, "b", requests[2].Header.Get(ecs.NamespaceHeader))
+	assert.Equal(t, "", requests[2].Header.Get(ecs.VPoolHeader))
+	assert.Equal(t, "c", requests[3].Header.Get(ecs.NamespaceHeader))
+}
+
+func TestNewCopiesClient(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	s := s3.New(newLocalSession(server.URL))
+	validate, unmarshalError := s.Handlers.Validate.Len(), s.Handlers.UnmarshalError.Len()
+	a := ecs.New(s, ecs.WithNamespace("a"))
+	b := ecs.New(s)
+
+	for _, client := range []*s3.S3{a.S3, b.S3, s} {
+		_, err := client.ListObjects(&s3.ListObjectsInput{Bucket: aws.String("bucket")})
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 3, len(requests))
+	assert.Equal(t, "a", requests[0].Header.Get(ecs.NamespaceHeader))
+	assert.Equal(t, "", requests[1].Header.Get(ecs.NamespaceHeader))
+	assert.Equal(t, "", requests[2].Header.Get(ecs.NamespaceHeader))
+	assert.Equal(t, validate, s.Handlers.Validate.Len())
+	assert.Equal(t, unmarshalError, s.Handlers.UnmarshalError.Len())
+}
+
+func TestWithHandlers(t *testing.T) {
+	var requests []recordedRequest
+	server := recordingServer(&requests)
+	defer server.Close()
+	client := ecs.NewWithOptions(newLocalSession(server.URL), ecs.WithHandlers(func(h *request.Handlers) {
+		h.Build.PushBack(func(r *request.Request) {
+			r.HTTPRequest.Header.Set("X-Test", r.Operation.Name)
+		})
+	}))
+
+	_, err := client.ListObjects(&s3.ListObjectsInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	_, err = client.ListBucketMetadataSearch(&ecs.ListBucketMetadataSearchInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	assert.Equal(t, "ListObjects", requests[0].Header.Get("X-Test"))
+	assert.Equal(t, "ListBucketMetadataSearch", requests[1].Header.Get("X-Test"))
+}
@@ -0,0 +1,56 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import "github.com/aws/aws-sdk-go/aws"
+
+// Access of a bucket while a site is temporarily unreachable (TSO), as
+// returned by AccessDuringOutage. The access is set by the IsStaleAllowed and
+// TSOReadOnly fields of CreateBucketInput and PutBucketIsStaleAllowedInput;
+// ListDuringOutage additionally allows listing the bucket during an outage.
+const (
+	// AccessDuringOutageDisabled is the access of buckets whose objects
+	// owned by an unreachable site cannot be accessed.
+	AccessDuringOutageDisabled = "Disabled"
+
+	// AccessDuringOutageReadOnly is the access of buckets whose objects
+	// can be read, possibly stale, but not written.
+	AccessDuringOutageReadOnly = "ReadOnly"
+
+	// AccessDuringOutageReadWrite is the access of buckets whose objects
+	// can be read, possibly stale, and written.
+	AccessDuringOutageReadWrite = "ReadWrite"
+)
+
+// AccessDuringOutage returns the access of the bucket during an outage.
+func (s *GetBucketIsStaleAllowedOutput) AccessDuringOutage() string {
+	return accessDuringOutage(s.IsStaleAllowed, s.TSOReadOnly)
+}
+
+// AccessDuringOutage returns the access of the bucket during an outage.
+func (s *BucketInfo) AccessDuringOutage() string {
+	return accessDuringOutage(s.IsStaleAllowed, s.TSOReadOnly)
+}
+
+func accessDuringOutage(isStaleAllowed, tsoReadOnly *bool) string {
+	switch {
+	case !aws.BoolValue(isStaleAllowed):
+		return AccessDuringOutageDisabled
+	case aws.BoolValue(tsoReadOnly):
+		return AccessDuringOutageReadOnly
+	}
+	return AccessDuringOutageReadWrite
+}
@@ -0,0 +1,81 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketIsStaleAllowed(t *testing.T) {
+	settings := http.Header{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			_, ok := r.URL.Query()["isstaleallowed"]
+			assert.True(t, ok)
+			for _, h := range []string{"x-emc-is-stale-allowed", "x-emc-list-during-outage", "x-emc-tso-read-only"} {
+				settings.Set(h, r.Header.Get(h))
+			}
+		case "HEAD":
+			for h := range settings {
+				w.Header().Set(h, settings.Get(h))
+			}
+		}
+	}))
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	out, err := client.GetBucketIsStaleAllowed(&ecs.GetBucketIsStaleAllowedInput{Bucket: aws.String("b")})
+	if assert.Nil(t, err) {
+		assert.Nil(t, out.IsStaleAllowed)
+		assert.Equal(t, ecs.AccessDuringOutageDisabled, out.AccessDuringOutage())
+	}
+
+	_, err = client.PutBucketIsStaleAllowed(&ecs.PutBucketIsStaleAllowedInput{
+		Bucket:           aws.String("b"),
+		IsStaleAllowed:   aws.Bool(true),
+		ListDuringOutage: aws.Bool(true),
+		TSOReadOnly:      aws.Bool(true),
+	})
+	assert.Nil(t, err)
+	out, err = client.GetBucketIsStaleAllowed(&ecs.GetBucketIsStaleAllowedInput{Bucket: aws.String("b")})
+	if assert.Nil(t, err) {
+		assert.True(t, aws.BoolValue(out.IsStaleAllowed))
+		assert.True(t, aws.BoolValue(out.ListDuringOutage))
+		assert.True(t, aws.BoolValue(out.TSOReadOnly))
+		assert.Equal(t, ecs.AccessDuringOutageReadOnly, out.AccessDuringOutage())
+	}
+
+	_, err = client.PutBucketIsStaleAllowed(&ecs.PutBucketIsStaleAllowedInput{
+		Bucket:         aws.String("b"),
+		IsStaleAllowed: aws.Bool(true),
+		TSOReadOnly:    aws.Bool(false),
+	})
+	assert.Nil(t, err)
+	info, err := client.GetBucketInfo("b")
+	if assert.Nil(t, err) {
+		assert.Equal(t, ecs.AccessDuringOutageReadWrite, info.AccessDuringOutage())
+	}
+
+	_, err = client.GetBucketIsStaleAllowed(&ecs.GetBucketIsStaleAllowedInput{})
+	assert.NotNil(t, err)
+}
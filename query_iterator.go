@@ -0,0 +1,141 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ObjectMatchIterator walks the object matches of a ListBucketQuery across
+// all result pages, fetching the next page only when the current one is
+// exhausted.
+//
+//	it := client.NewObjectMatchIterator(ctx, input)
+//	for it.Next() {
+//	    fmt.Println(*it.Match().ObjectName)
+//	}
+//	if err := it.Err(); err != nil {
+//	    // handle error
+//	}
+type ObjectMatchIterator struct {
+	ctx    aws.Context
+	client ListBucketQueryAPI
+	input  ListBucketQueryInput
+	opts   []request.Option
+	last   bool
+	page   []*EcsObjectMatch
+	match  *EcsObjectMatch
+	err    error
+}
+
+// ListBucketQueryAPI is the operation object match iterators read pages
+// with. It is implemented by S3 and by implementations of ecsiface.ECSAPI.
+type ListBucketQueryAPI interface {
+	ListBucketQueryWithContext(aws.Context, *ListBucketQueryInput, ...request.Option) (*ListBucketQueryOutput, error)
+}
+
+// NewObjectMatchIterator returns an iterator over the object matches of the
+// query described by input. Iteration stops when ctx is done.
+func (c *S3) NewObjectMatchIterator(ctx aws.Context, input *ListBucketQueryInput, opts ...request.Option) *ObjectMatchIterator {
+	return IterateObjectMatches(ctx, c, input, opts...)
+}
+
+// IterateObjectMatches is the same as S3.NewObjectMatchIterator, reading the
+// pages with client.
+func IterateObjectMatches(ctx aws.Context, client ListBucketQueryAPI, input *ListBucketQueryInput, opts ...request.Option) *ObjectMatchIterator {
+	it := &ObjectMatchIterator{ctx: ctx, client: client, opts: opts}
+	if input != nil {
+		it.input = *input
+	}
+	return it
+}
+
+// Next advances the iterator to the next object match. It returns false when
+// there are no more matches or an error occurred.
+func (it *ObjectMatchIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+	for len(it.page) == 0 {
+		if it.last {
+			it.match = nil
+			return false
+		}
+		input := it.input
+		out, err := it.client.ListBucketQueryWithContext(it.ctx, &input, it.opts...)
+		if err != nil {
+			it.err = err
+			it.match = nil
+			return false
+		}
+		it.page = out.ObjectMatches
+		// Stop on the last page, and on a marker that does not move on.
+		next := aws.StringValue(out.NextMarker)
+		it.last = next == "" || next == aws.StringValue(it.input.Marker)
+		it.input.Marker = out.NextMarker
+	}
+	it.match, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Match returns the current object match.
+func (it *ObjectMatchIterator) Match() *EcsObjectMatch {
+	return it.match
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ObjectMatchIterator) Err() error {
+	return it.err
+}
+
+// ListBucketQueryMatches streams the object matches of the query described by
+// input on the returned channel. The channel is closed when all pages have
+// been read, ctx is done or a request fails; the second channel then yields
+// the error, if any, and is closed.
+func (c *S3) ListBucketQueryMatches(ctx aws.Context, input *ListBucketQueryInput, opts ...request.Option) (<-chan *EcsObjectMatch, <-chan error) {
+	return StreamObjectMatches(ctx, c, input, opts...)
+}
+
+// StreamObjectMatches is the same as S3.ListBucketQueryMatches, reading the
+// pages with client.
+func StreamObjectMatches(ctx aws.Context, client ListBucketQueryAPI, input *ListBucketQueryInput, opts ...request.Option) (<-chan *EcsObjectMatch, <-chan error) {
+	matches := make(chan *EcsObjectMatch)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(matches)
+
+		it := IterateObjectMatches(ctx, client, input, opts...)
+		for it.Next() {
+			select {
+			case matches <- it.Match():
+			case <-ctx.Done():
+				errs <- ctx.Err()
+				return
+			}
+		}
+		if err := it.Err(); err != nil {
+			errs <- err
+		}
+	}()
+
+	return matches, errs
+}
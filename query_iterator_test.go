@@ -0,0 +1,127 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// newLocalClient returns a client for a local test server.
+func newLocalClient(endpoint string) *ecs.S3 {
+	return ecs.New(s3.New(newLocalSession(endpoint)))
+}
+
+// newLocalSession returns a session for a path-style local server.
+func newLocalSession(endpoint string) *session.Session {
+	return session.Must(session.NewSession(&aws.Config{
+		Credentials:      credentials.NewStaticCredentials("AKID", "SECRET", ""),
+		Endpoint:         aws.String(endpoint),
+		Region:           aws.String("us-east-1"),
+		S3ForcePathStyle: aws.Bool(true),
+		MaxRetries:       aws.Int(0),
+	}))
+}
+
+// queryPages serves the object names of pages as ListBucketQuery results.
+func queryPages(pages [][]string, markers *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		marker := r.URL.Query().Get("marker")
+		*markers = append(*markers, marker)
+
+		n := 0
+		fmt.Sscanf(marker, "page%d", &n)
+		next := "NO MORE PAGES"
+		if n+1 < len(pages) {
+			next = fmt.Sprintf("page%d", n+1)
+		}
+
+		fmt.Fprintf(w, "<BucketQueryResult><Name>bucket</Name><NextMarker>%s</NextMarker><MaxKeys>2</MaxKeys><ObjectMatches>", next)
+		for _, name := range pages[n] {
+			fmt.Fprintf(w, "<object><objectName>%s</objectName></object>", name)
+		}
+		fmt.Fprint(w, "</ObjectMatches></BucketQueryResult>")
+	}))
+}
+
+func TestListBucketQueryPages(t *testing.T) {
+	var markers []string
+	server := queryPages([][]string{{"a", "b"}, {"c", "d"}, {"e"}}, &markers)
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	input := &ecs.ListBucketQueryInput{Bucket: aws.String("bucket"), Query: aws.String("Size>3")}
+
+	var names []string
+	var last []bool
+	err := client.ListBucketQueryPages(input, func(page *ecs.ListBucketQueryOutput, lastPage bool) bool {
+		for _, m := range page.ObjectMatches {
+			names = append(names, *m.ObjectName)
+		}
+		last = append(last, lastPage)
+		return true
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, names)
+	assert.Equal(t, []bool{false, false, true}, last)
+	assert.Equal(t, []string{"", "page1", "page2"}, markers)
+	assert.Nil(t, input.Marker)
+
+	markers = nil
+	pages := 0
+	err = client.ListBucketQueryPages(input, func(page *ecs.ListBucketQueryOutput, lastPage bool) bool {
+		pages++
+		return false
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pages)
+}
+
+func TestObjectMatchIterator(t *testing.T) {
+	var markers []string
+	server := queryPages([][]string{{"a", "b"}, {}, {"c"}}, &markers)
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	input := &ecs.ListBucketQueryInput{Bucket: aws.String("bucket"), Query: aws.String("Size>3")}
+
+	var names []string
+	it := client.NewObjectMatchIterator(context.Background(), input)
+	for it.Next() {
+		names = append(names, *it.Match().ObjectName)
+	}
+	assert.Nil(t, it.Err())
+	assert.Equal(t, []string{"a", "b", "c"}, names)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	matches, errs := client.ListBucketQueryMatches(ctx, input)
+	m := <-matches
+	assert.Equal(t, "a", *m.ObjectName)
+	cancel()
+	for range matches {
+	}
+	assert.Equal(t, context.Canceled, <-errs)
+}
@@ -0,0 +1,329 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Settings of the ParallelRangeUploader returned by
+// NewParallelRangeUploader.
+const (
+	DefaultRangeUploadPartSize    int64 = 4 * 1024 * 1024
+	DefaultRangeUploadConcurrency       = 5
+)
+
+// ErrCodeObjectSizeMismatch is the error code returned when the size of an
+// object does not match the data a ParallelRangeUploader wrote or a
+// Downloader read.
+const ErrCodeObjectSizeMismatch = "ObjectSizeMismatch"
+
+// RangeUploadAPI is the operations a ParallelRangeUploader writes objects
+// with. It is implemented by S3 and by implementations of ecsiface.ECSAPI.
+type RangeUploadAPI interface {
+	PutObjectExtensionWithContext(aws.Context, *PutObjectInput, ...request.Option) (*PutObjectOutput, error)
+	PutObjectRetentionWithContext(aws.Context, *PutObjectRetentionInput, ...request.Option) (*PutObjectRetentionOutput, error)
+	HeadObjectExtensionWithContext(aws.Context, *s3.HeadObjectInput, ...request.Option) (*HeadObjectOutput, error)
+	DeleteObjectWithContext(aws.Context, *s3.DeleteObjectInput, ...request.Option) (*s3.DeleteObjectOutput, error)
+}
+
+// A ParallelRangeUploader writes objects with byte range updates, reading
+// their data as a stream. It creates the object with the first part, then
+// writes the following parts at their offsets, up to Concurrency at a time.
+// Unlike a multipart upload there is no complete step, which makes it faster
+// for many mid-size objects.
+//
+// A range that fails is written again on its own, as decided by Retryer.
+// Once all the ranges are written, the size of the object and its
+// x-emc-content-md5 are checked against the data read, and the retention of
+// the input is set: an object under retention cannot be updated, so it
+// cannot be written this way in a bucket with a retention period. A
+// ParallelRangeUploader may be used concurrently.
+//
+// The object exists from the first part on, so while the ranges are written
+// readers can see it partially written, with any of the ranges after the
+// first part missing. Use an Uploader where readers must only see whole
+// objects.
+type ParallelRangeUploader struct {
+	// PartSize is the size of the ranges written. Each upload in progress
+	// buffers up to Concurrency+1 parts.
+	PartSize int64
+	// Concurrency is the number of ranges of an upload written at a time.
+	Concurrency int
+	// Retryer decides whether and when a failed range is written again. The
+	// retries come on top of those of the client. Failed ranges are not
+	// retried if Retryer is nil.
+	Retryer *Retryer
+	// RequestOptions apply to every request of the uploads.
+	RequestOptions []request.Option
+
+	S3 RangeUploadAPI
+}
+
+// NewParallelRangeUploader returns a ParallelRangeUploader writing objects
+// with client and retrying failed ranges with NewRetryer, with the default
+// settings changed by options.
+func NewParallelRangeUploader(client RangeUploadAPI, options ...func(*ParallelRangeUploader)) *ParallelRangeUploader {
+	u := &ParallelRangeUploader{
+		PartSize:    DefaultRangeUploadPartSize,
+		Concurrency: DefaultRangeUploadConcurrency,
+		Retryer:     NewRetryer(),
+		S3:          client,
+	}
+	for _, option := range options {
+		option(u)
+	}
+	return u
+}
+
+// Upload writes the object described by input, reading its data from Body
+// until io.EOF. Range and ContentLength must not be set.
+//
+// If the upload fails after the object was created, the version of the
+// object it created is deleted.
+// If the object was written but its size or x-emc-content-md5 does not match
+// the data read, Upload returns the output with an error with the code
+// ErrCodeObjectSizeMismatch or ErrCodeContentMD5Mismatch.
+func (u *ParallelRangeUploader) Upload(input *PutObjectInput, options ...func(*ParallelRangeUploader)) (*UploadOutput, error) {
+	return u.UploadWithContext(aws.BackgroundContext(), input, options...)
+}
+
+// UploadWithContext is the same as Upload with the addition of the ability to
+// pass a context. The upload stops when ctx is done.
+func (u *ParallelRangeUploader) UploadWithContext(ctx aws.Context, input *PutObjectInput, options ...func(*ParallelRangeUploader)) (*UploadOutput, error) {
+	settings := *u
+	for _, option := range options {
+		option(&settings)
+	}
+	if err := settings.validate(input); err != nil {
+		return nil, err
+	}
+
+	up := &rangeUpload{ParallelRangeUploader: &settings, partReader: newPartReader(input.Body, settings.PartSize), ctx: ctx, input: input}
+	out, err := up.upload()
+	if err != nil && out == nil && up.created {
+		up.delete()
+	}
+	return out, err
+}
+
+func (u *ParallelRangeUploader) validate(input *PutObjectInput) error {
+	invalidParams := request.ErrInvalidParams{Context: "ParallelRangeUploader"}
+	if u.PartSize < 1 {
+		invalidParams.Add(request.NewErrParamMinValue("PartSize", 1))
+	}
+	if u.Concurrency < 1 {
+		invalidParams.Add(request.NewErrParamMinValue("Concurrency", 1))
+	}
+	if input == nil {
+		invalidParams.Add(request.NewErrParamRequired("PutObjectInput"))
+		return invalidParams
+	}
+	if input.Body == nil {
+		invalidParams.Add(request.NewErrParamRequired("Body"))
+	}
+	if input.Range != nil {
+		invalidParams.Add(NewErrParamFormat("Range", "cannot be uploaded, use UpdateObjectRange or AppendObject"))
+	}
+	if input.ContentLength != nil {
+		invalidParams.Add(NewErrParamFormat("ContentLength", "is computed by the upload"))
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// rangeUpload is a range upload in progress.
+type rangeUpload struct {
+	*ParallelRangeUploader
+	*partReader
+	ctx   aws.Context
+	input *PutObjectInput
+	// created is set once the object is created.
+	created bool
+	// versionID is the version the object was created with, if the bucket is
+	// versioned.
+	versionID *string
+}
+
+func (u *rangeUpload) upload() (*UploadOutput, error) {
+	first, err := u.nextPart()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	// The retention is set last, as an object under retention cannot be
+	// updated.
+	create := *u.input
+	create.RetentionPeriod = nil
+	create.RetentionPolicy = nil
+	created, err := u.put(u.ctx, &create, first)
+	if err != nil {
+		return nil, err
+	}
+	u.created = true
+	u.versionID = created.VersionId
+
+	size := int64(len(first))
+	if err != io.EOF {
+		if size, err = u.writeRanges(size); err != nil {
+			return nil, err
+		}
+	}
+
+	head, err := u.S3.HeadObjectExtensionWithContext(u.ctx, &s3.HeadObjectInput{
+		Bucket:               u.input.Bucket,
+		Key:                  u.input.Key,
+		RequestPayer:         u.input.RequestPayer,
+		SSECustomerAlgorithm: u.input.SSECustomerAlgorithm,
+		SSECustomerKey:       u.input.SSECustomerKey,
+		SSECustomerKeyMD5:    u.input.SSECustomerKeyMD5,
+	}, u.RequestOptions...)
+	if err != nil {
+		return nil, err
+	}
+	out := &UploadOutput{ContentMD5EMC: head.ContentMD5EMC, ETag: head.ETag, VersionId: head.VersionId}
+	if head.ContentLength != nil && *head.ContentLength != size {
+		return out, awserr.New(ErrCodeObjectSizeMismatch, fmt.Sprintf("size of %s/%s is %d, expected %d",
+			aws.StringValue(u.input.Bucket), aws.StringValue(u.input.Key), *head.ContentLength, size), nil)
+	}
+	if err := u.verify(u.input, head.ContentMD5EMC); err != nil {
+		return out, err
+	}
+
+	if u.input.RetentionPeriod != nil || u.input.RetentionPolicy != nil {
+		retention := &PutObjectRetentionInput{
+			Bucket:          u.input.Bucket,
+			Key:             u.input.Key,
+			RetentionPeriod: u.input.RetentionPeriod,
+			RetentionPolicy: u.input.RetentionPolicy,
+		}
+		for retries := 0; ; retries++ {
+			_, err = u.S3.PutObjectRetentionWithContext(u.ctx, retention, u.RequestOptions...)
+			if err == nil || !u.Retryer.retryAfter(u.ctx, err, retries) {
+				break
+			}
+		}
+		if err != nil {
+			return out, err
+		}
+	}
+	return out, nil
+}
+
+// writeRanges writes the parts following the first one, of size offset, and
+// returns the size of the object.
+func (u *rangeUpload) writeRanges(offset int64) (int64, error) {
+	ctx, cancel := context.WithCancel(u.ctx)
+	defer cancel()
+
+	type part struct {
+		offset int64
+		data   []byte
+	}
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		failure error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failure == nil {
+			failure = err
+			cancel()
+		}
+	}
+	parts := make(chan part)
+	for i := 0; i < u.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range parts {
+				input := &PutObjectInput{
+					Bucket:               u.input.Bucket,
+					Key:                  u.input.Key,
+					Range:                aws.String(OverwriteRange(p.offset)),
+					RequestPayer:         u.input.RequestPayer,
+					SSECustomerAlgorithm: u.input.SSECustomerAlgorithm,
+					SSECustomerKey:       u.input.SSECustomerKey,
+					SSECustomerKeyMD5:    u.input.SSECustomerKeyMD5,
+				}
+				if _, err := u.put(ctx, input, p.data); err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	for {
+		data, err := u.nextPart()
+		if err != nil && err != io.EOF {
+			fail(err)
+			break
+		}
+		if len(data) > 0 {
+			select {
+			case parts <- part{offset, data}:
+				offset += int64(len(data))
+			case <-ctx.Done():
+				fail(ctx.Err())
+			}
+		}
+		if err == io.EOF || ctx.Err() != nil {
+			break
+		}
+	}
+	close(parts)
+	wg.Wait()
+
+	if failure == context.Canceled || failure == context.DeadlineExceeded {
+		return 0, awserr.New(request.CanceledErrorCode, "upload context canceled", failure)
+	}
+	return offset, failure
+}
+
+// put writes data with input, retrying failures.
+func (u *rangeUpload) put(ctx aws.Context, input *PutObjectInput, data []byte) (*PutObjectOutput, error) {
+	for retries := 0; ; retries++ {
+		input.Body = bytes.NewReader(data)
+		out, err := u.S3.PutObjectExtensionWithContext(ctx, input, u.RequestOptions...)
+		if err == nil || !u.Retryer.retryAfter(ctx, err, retries) {
+			return out, err
+		}
+	}
+}
+
+// delete deletes the version of the object a failed upload created, even if
+// the context of the upload is done, so that a versioned bucket is left with
+// the versions it had. Failures are ignored.
+func (u *rangeUpload) delete() {
+	u.S3.DeleteObjectWithContext(aws.BackgroundContext(), &s3.DeleteObjectInput{
+		Bucket:       u.input.Bucket,
+		Key:          u.input.Key,
+		RequestPayer: u.input.RequestPayer,
+		VersionId:    u.versionID,
+	}, u.RequestOptions...)
+}
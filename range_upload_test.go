@@ -0,0 +1,298 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRangeUploader(client ecs.RangeUploadAPI) *ecs.ParallelRangeUploader {
+	return ecs.NewParallelRangeUploader(client, func(u *ecs.ParallelRangeUploader) {
+		u.PartSize = 1024
+		u.Concurrency = 4
+		u.Retryer = testRetryer()
+	})
+}
+
+func rangeFault(byteRange string, count int) ecstest.Fault {
+	return ecstest.Fault{
+		Match: func(r *http.Request) bool {
+			return r.Method == "PUT" && r.Header.Get("Range") == byteRange
+		},
+		Count:      count,
+		StatusCode: http.StatusServiceUnavailable,
+		Code:       "ServiceUnavailable",
+	}
+}
+
+func TestParallelRangeUpload(t *testing.T) {
+	server, log := newUploadServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	data, sum := uploadData(10*1024 + 5)
+	out, err := testRangeUploader(client).Upload(&ecs.PutObjectInput{
+		Bucket:          aws.String("b"),
+		Key:             aws.String("k"),
+		Body:            bytes.NewReader(data),
+		Metadata:        map[string]*string{"color": aws.String("red")},
+		RetentionPeriod: aws.Int64(3600),
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+	}
+
+	ranges := log.filter(func(r *http.Request) bool {
+		return r.Method == "PUT" && r.Header.Get("Range") != ""
+	})
+	assert.Len(t, ranges, 10)
+
+	get, err := client.GetObjectExtension(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		read, _ := ioutil.ReadAll(get.Body)
+		get.Body.Close()
+		assert.True(t, bytes.Equal(data, read))
+		assert.Equal(t, "red", aws.StringValue(get.Metadata["Color"]))
+		assert.Equal(t, int64(3600), aws.Int64Value(get.RetentionPeriod))
+	}
+}
+
+func TestParallelRangeUploadContentMD5(t *testing.T) {
+	server, log := newUploadServer(t)
+	defer server.Close()
+
+	data, sum := uploadData(4*1024 + 5)
+	out, err := testRangeUploader(server.Client()).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   bytes.NewReader(data),
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+	}
+	// the first PUT and the ranges are checked with x-emc-content-md5 of the
+	// whole object only, never with a Content-MD5 of a part
+	puts := log.filter(func(r *http.Request) bool {
+		return r.Method == "PUT"
+	})
+	assert.Len(t, puts, 5)
+	for _, r := range puts {
+		assert.Equal(t, "", r.Header.Get("Content-MD5"), r.Header.Get("Range"))
+	}
+}
+
+func TestParallelRangeUploadRetry(t *testing.T) {
+	server, _ := newUploadServer(t)
+	defer server.Close()
+	client := server.Client()
+	server.ClearFaults()
+	server.AddFault(rangeFault(ecs.OverwriteRange(2048), 2))
+
+	data, sum := uploadData(4 * 1024)
+	out, err := testRangeUploader(client).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   bytes.NewReader(data),
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+	}
+}
+
+func TestParallelRangeUploadFailure(t *testing.T) {
+	server, _ := newUploadServer(t)
+	defer server.Close()
+	client := server.Client()
+	server.ClearFaults()
+	server.AddFault(rangeFault(ecs.OverwriteRange(2048), 0))
+
+	data, _ := uploadData(4 * 1024)
+	_, err := testRangeUploader(client).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   bytes.NewReader(data),
+	})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "ServiceUnavailable", err.(awserr.Error).Code())
+	}
+
+	// the partial object is deleted
+	_, err = client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.NotNil(t, err)
+}
+
+func TestParallelRangeUploadFailureVersioned(t *testing.T) {
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "PUT" && r.Header.Get("Range") == "":
+			w.Header().Set("x-amz-version-id", "v2")
+		case r.Method == "PUT":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("<Error><Code>InvalidRange</Code><Message>invalid range</Message></Error>"))
+		case r.Method == "DELETE":
+			deleted = append(deleted, r.URL.Query().Get("versionId"))
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	data, _ := uploadData(4 * 1024)
+	_, err := testRangeUploader(newLocalClient(server.URL)).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   bytes.NewReader(data),
+	})
+	assert.True(t, ecs.IsInvalidRange(err))
+	// only the version the upload created is deleted
+	assert.Equal(t, []string{"v2"}, deleted)
+}
+
+func TestParallelRangeUploadSizeMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			w.Header().Set("Content-Length", "1")
+		}
+	}))
+	defer server.Close()
+
+	out, err := testRangeUploader(newLocalClient(server.URL)).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   strings.NewReader("data"),
+	})
+	assert.NotNil(t, out)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, ecs.ErrCodeObjectSizeMismatch, err.(awserr.Error).Code())
+	}
+}
+
+func TestParallelRangeUploadFake(t *testing.T) {
+	fake := ecstest.NewFake()
+	_, err := fake.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	uploader := testRangeUploader(fake)
+
+	for _, size := range []int64{0, 1000, 1024, 5000} {
+		data, sum := uploadData(size)
+		out, err := uploader.Upload(&ecs.PutObjectInput{
+			Bucket:          aws.String("b"),
+			Key:             aws.String("k"),
+			Body:            bytes.NewReader(data),
+			RetentionPolicy: aws.String("policy"),
+		})
+		if assert.Nil(t, err, size) {
+			assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC), size)
+		}
+	}
+}
+
+func BenchmarkParallelRangeUpload(b *testing.B) {
+	fake := ecstest.NewFake()
+	fake.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	data, _ := uploadData(4 * 1024 * 1024)
+
+	for _, concurrency := range []int{1, 4, 16} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			uploader := ecs.NewParallelRangeUploader(fake, func(u *ecs.ParallelRangeUploader) {
+				u.PartSize = 256 * 1024
+				u.Concurrency = concurrency
+			})
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				_, err := uploader.Upload(&ecs.PutObjectInput{
+					Bucket: aws.String("b"),
+					Key:    aws.String("k"),
+					Body:   bytes.NewReader(data),
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkPutObject(b *testing.B) {
+	fake := ecstest.NewFake()
+	fake.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	data, _ := uploadData(4 * 1024 * 1024)
+
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		_, err := fake.PutObjectExtension(&ecs.PutObjectInput{
+			Bucket: aws.String("b"),
+			Key:    aws.String("k"),
+			Body:   bytes.NewReader(data),
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkUploadLatency compares range and multipart uploads to a server
+// answering each request after a delay, as ECS over a network would.
+func BenchmarkUploadLatency(b *testing.B) {
+	server := ecstest.NewServer()
+	defer server.Close()
+	client := server.Client()
+	client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	server.SetLatency(5 * time.Millisecond)
+	data, _ := uploadData(2 * ecs.MinUploadPartSize)
+
+	rangeUploader := ecs.NewParallelRangeUploader(client, func(u *ecs.ParallelRangeUploader) {
+		u.PartSize = ecs.MinUploadPartSize
+	})
+	uploader := ecs.NewUploader(client)
+	uploaders := map[string]func(*ecs.PutObjectInput) (*ecs.UploadOutput, error){
+		"range": func(input *ecs.PutObjectInput) (*ecs.UploadOutput, error) {
+			return rangeUploader.Upload(input)
+		},
+		"multipart": func(input *ecs.PutObjectInput) (*ecs.UploadOutput, error) {
+			return uploader.Upload(input)
+		},
+	}
+	for _, name := range []string{"range", "multipart"} {
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				_, err := uploaders[name](&ecs.PutObjectInput{
+					Bucket: aws.String("b"),
+					Key:    aws.String("k"),
+					Body:   bytes.NewReader(data),
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// ErrCodeObjectUnderRetention is the error code ECS returns when an object
+// cannot be deleted or modified because its retention has not expired.
+const ErrCodeObjectUnderRetention = "ObjectUnderRetention"
+
+// ErrCodeRetentionReduction is the error code returned when PutBucketRetention
+// would reduce the retention period of a compliance-enabled bucket.
+const ErrCodeRetentionReduction = "RetentionReduction"
+
+// A RetentionError is returned when ECS refuses to delete or overwrite an
+// object whose retention period has not expired. It wraps the original
+// request failure.
+type RetentionError struct {
+	awserr.RequestFailure
+}
+
+// RetentionExpiresAt returns the time an object last modified at lastModified
+// with a retention period in seconds can be deleted.
+func RetentionExpiresAt(lastModified time.Time, period int64) time.Time {
+	return lastModified.Add(time.Duration(period) * time.Second)
+}
+
+// RetentionExpiresAt returns when the retention period of the object expires.
+// It returns false when the object has no retention period, e.g. when it is
+// only governed by a retention policy.
+func (s *HeadObjectOutput) RetentionExpiresAt() (time.Time, bool) {
+	return retentionExpiresAt(s.LastModified, s.RetentionPeriod)
+}
+
+// RetentionExpiresAt returns when the retention period of the object expires.
+// It returns false when the object has no retention period, e.g. when it is
+// only governed by a retention policy.
+func (s *GetObjectRetentionOutput) RetentionExpiresAt() (time.Time, bool) {
+	return retentionExpiresAt(s.LastModified, s.RetentionPeriod)
+}
+
+func retentionExpiresAt(lastModified *time.Time, period *int64) (time.Time, bool) {
+	if lastModified == nil || period == nil {
+		return time.Time{}, false
+	}
+	return RetentionExpiresAt(aws.TimeValue(lastModified), *period), true
+}
+
+const retentionReductionCheckName = "ecs.RetentionReductionCheck"
+
+// WithoutRetentionReductionCheck returns a request option skipping the check
+// PutBucketRetention makes that the retention period of a compliance-enabled
+// bucket is not reduced, and the HEAD request it costs. ECS refuses the
+// reduction all the same.
+func WithoutRetentionReductionCheck() request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Build.RemoveByName(retentionReductionCheckName)
+	}
+}
+
+// checkRetentionReduction fails a PutBucketRetention request that would
+// reduce the retention period of a compliance-enabled bucket.
+func (c *S3) checkRetentionReduction(r *request.Request) {
+	if r.Error != nil || !r.ParamsFilled() {
+		return
+	}
+	in, ok := r.Params.(*PutBucketRetentionInput)
+	if !ok {
+		return
+	}
+	r.Error = c.CheckBucketRetentionReductionWithContext(r.Context(), aws.StringValue(in.Bucket), aws.Int64Value(in.RetentionPeriod))
+}
+
+// CheckBucketRetentionReduction returns an error with the code
+// ErrCodeRetentionReduction if bucket is compliance-enabled and period is
+// shorter than its retention period, as PutBucketRetention does before it
+// sends the request. The bucket may change between the check and a later PUT.
+func (c *S3) CheckBucketRetentionReduction(bucket string, period int64) error {
+	return c.CheckBucketRetentionReductionWithContext(aws.BackgroundContext(), bucket, period)
+}
+
+// CheckBucketRetentionReductionWithContext is the same as CheckBucketRetentionReduction with the addition of
+// the ability to pass a context and additional request options.
+func (c *S3) CheckBucketRetentionReductionWithContext(ctx aws.Context, bucket string, period int64, opts ...request.Option) error {
+	current, err := c.GetBucketRetentionWithContext(ctx, &GetBucketRetentionInput{Bucket: aws.String(bucket)}, opts...)
+	if err != nil {
+		return err
+	}
+	return CheckRetentionReduction(bucket, period, current)
+}
+
+// CheckRetentionReduction returns an error with the code
+// ErrCodeRetentionReduction if period would reduce current, the retention of
+// a compliance-enabled bucket. It is used by implementations of
+// ecsiface.ECSAPI.
+func CheckRetentionReduction(bucket string, period int64, current *GetBucketRetentionOutput) error {
+	if aws.BoolValue(current.ComplianceEnabled) && period < aws.Int64Value(current.RetentionPeriod) {
+		return awserr.New(ErrCodeRetentionReduction,
+			fmt.Sprintf("retention period of compliance-enabled bucket %s can only be extended, current period is %d",
+				bucket, aws.Int64Value(current.RetentionPeriod)), nil)
+	}
+	return nil
+}
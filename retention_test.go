@@ -0,0 +1,129 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObjectRetention(t *testing.T) {
+	modified := time.Date(2017, 6, 1, 10, 30, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			_, ok := r.URL.Query()["retention"]
+			assert.True(t, ok)
+			assert.Equal(t, "3600", r.Header.Get("x-emc-retention-period"))
+		case "HEAD":
+			w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+			w.Header().Set("x-emc-retention-period", "3600")
+		case "DELETE":
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte("<Error><Code>ObjectUnderRetention</Code><Message>The object is under retention and can't be deleted or modified.</Message></Error>"))
+		}
+	}))
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	_, err := client.PutObjectRetention(&ecs.PutObjectRetentionInput{
+		Bucket:          aws.String("bucket"),
+		Key:             aws.String("key"),
+		RetentionPeriod: aws.Int64(3600),
+	})
+	assert.Nil(t, err)
+
+	out, err := client.GetObjectRetention(&ecs.GetObjectRetentionInput{Bucket: aws.String("bucket"), Key: aws.String("key")})
+	assert.Nil(t, err)
+	expires, ok := out.RetentionExpiresAt()
+	assert.True(t, ok)
+	assert.Equal(t, modified.Add(time.Hour), expires)
+
+	_, ok = (&ecs.HeadObjectOutput{}).SetRetentionPolicy("policy").RetentionExpiresAt()
+	assert.False(t, ok)
+
+	_, err = client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String("bucket"), Key: aws.String("key")})
+	if assert.IsType(t, &ecs.RetentionError{}, err) {
+		assert.Equal(t, ecs.ErrCodeObjectUnderRetention, err.(*ecs.RetentionError).Code())
+		assert.Equal(t, http.StatusConflict, err.(*ecs.RetentionError).StatusCode())
+	}
+
+	_, err = client.PutObjectRetention(&ecs.PutObjectRetentionInput{Bucket: aws.String("bucket"), Key: aws.String("key")})
+	assert.NotNil(t, err)
+}
+
+func TestBucketRetention(t *testing.T) {
+	var puts []string
+	var heads int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+			_, ok := r.URL.Query()["retentionperiod"]
+			assert.True(t, ok)
+			puts = append(puts, r.Header.Get("x-emc-retention-period"))
+		case "HEAD":
+			heads++
+			w.Header().Set("x-emc-compliance-enabled", "true")
+			w.Header().Set("x-emc-retention-period", "3600")
+		}
+	}))
+	defer server.Close()
+	client := newLocalClient(server.URL)
+
+	out, err := client.GetBucketRetention(&ecs.GetBucketRetentionInput{Bucket: aws.String("bucket")})
+	assert.Nil(t, err)
+	assert.True(t, *out.ComplianceEnabled)
+	assert.Equal(t, int64(3600), *out.RetentionPeriod)
+
+	_, err = client.PutBucketRetention(&ecs.PutBucketRetentionInput{Bucket: aws.String("bucket"), RetentionPeriod: aws.Int64(7200)})
+	assert.Nil(t, err)
+
+	// The reduction fails before the request is sent.
+	_, err = client.PutBucketRetention(&ecs.PutBucketRetentionInput{Bucket: aws.String("bucket"), RetentionPeriod: aws.Int64(60)})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, ecs.ErrCodeRetentionReduction, err.(awserr.Error).Code())
+	}
+	assert.Equal(t, []string{"7200"}, puts)
+
+	// Without the check, the reduction is left to ECS and no HEAD is sent.
+	heads = 0
+	_, err = client.PutBucketRetentionWithContext(aws.BackgroundContext(),
+		&ecs.PutBucketRetentionInput{Bucket: aws.String("bucket"), RetentionPeriod: aws.Int64(60)},
+		ecs.WithoutRetentionReductionCheck())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"7200", "60"}, puts)
+	assert.Equal(t, 0, heads)
+
+	heads = 0
+	unchecked := ecs.NewWithOptions(newLocalSession(server.URL), ecs.WithoutRetentionReductionCheck())
+	_, err = unchecked.PutBucketRetention(&ecs.PutBucketRetentionInput{Bucket: aws.String("bucket"), RetentionPeriod: aws.Int64(30)})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, heads)
+
+	err = client.CheckBucketRetentionReduction("bucket", 60)
+	if assert.NotNil(t, err) {
+		assert.Equal(t, ecs.ErrCodeRetentionReduction, err.(awserr.Error).Code())
+	}
+	assert.Nil(t, client.CheckBucketRetentionReduction("bucket", 3600))
+}
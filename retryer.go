@@ -0,0 +1,247 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+// Default settings of the Retryer returned by NewRetryer.
+const (
+	DefaultRetryerMaxRetries    = 3
+	DefaultRetryerMinDelay      = 100 * time.Millisecond
+	DefaultRetryerThrottleDelay = 500 * time.Millisecond
+	DefaultRetryerMaxDelay      = 20 * time.Second
+)
+
+// A Retryer is a request.Retryer for ECS. It retries connection errors,
+// server errors and the 503 responses ECS returns while a site fails over,
+// backing off exponentially with jitter. It does not retry:
+//
+//   - appends, which are not idempotent: the data may have been written even
+//     if the response was lost, and a retry would append it again
+//   - reads refused with ErrCodeStaleReadNotAllowed, as the outage of the
+//     site owning the object lasts much longer than the backoff
+//   - canceled requests and client errors
+//
+// Install a Retryer with WithRetryer; the SDK only consults a Retryer set in
+// aws.Config about connection errors if EnforceShouldRetryCheck is set too.
+// A Retryer may be shared by clients and used concurrently.
+type Retryer struct {
+	// NumMaxRetries is the maximum number of retries of a request.
+	NumMaxRetries int
+	// MinDelay is the delay before the first retry. It doubles with each
+	// retry up to MaxDelay.
+	MinDelay time.Duration
+	// ThrottleDelay replaces MinDelay when ECS asks to slow down.
+	ThrottleDelay time.Duration
+	MaxDelay      time.Duration
+	// Budget, if not nil, limits the retries of all the requests using the
+	// Retryer, so that a failing site is not flooded with retries.
+	Budget *RetryBudget
+}
+
+var _ request.Retryer = (*Retryer)(nil)
+
+// NewRetryer returns a Retryer with the default settings and no budget.
+func NewRetryer() *Retryer {
+	return &Retryer{
+		NumMaxRetries: DefaultRetryerMaxRetries,
+		MinDelay:      DefaultRetryerMinDelay,
+		ThrottleDelay: DefaultRetryerThrottleDelay,
+		MaxDelay:      DefaultRetryerMaxDelay,
+	}
+}
+
+// WithRetryer returns a request option retrying requests with retryer. When
+// retryer is an ecs.Retryer, it decides on every failure, including
+// connection errors.
+func WithRetryer(retryer request.Retryer) request.Option {
+	return requestOption(func(r *request.Request) {
+		r.Retryer = retryer
+		if _, ok := retryer.(*Retryer); ok {
+			r.Config.EnforceShouldRetryCheck = aws.Bool(true)
+		}
+	})
+}
+
+// MaxRetries returns the maximum number of retries of a request.
+func (r *Retryer) MaxRetries() int {
+	return r.NumMaxRetries
+}
+
+// ShouldRetry reports whether the failed request req should be retried. A
+// retry is taken from the budget, if any.
+func (r *Retryer) ShouldRetry(req *request.Request) bool {
+	if req.RetryCount >= r.MaxRetries() || !retryable(req) {
+		return false
+	}
+	return r.Budget == nil || r.Budget.withdraw()
+}
+
+// RetryRules returns the delay before the next retry of req: the base delay
+// doubled for each previous retry, at most MaxDelay, of which the second half
+// is random.
+func (r *Retryer) RetryRules(req *request.Request) time.Duration {
+	delay := r.MinDelay
+	if isThrottle(req) {
+		delay = r.ThrottleDelay
+	}
+	for i := 0; i < req.RetryCount && delay < r.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(jitter.Int63n(int64(delay/2)+1))
+}
+
+// retryAfter reports whether an operation that failed with err after retries
+// retries should be run again, after waiting for the delay of r unless ctx is
+// done. It lets helpers making many requests retry each of them on its own.
+// A nil Retryer retries nothing.
+func (r *Retryer) retryAfter(ctx aws.Context, err error, retries int) bool {
+	if r == nil {
+		return false
+	}
+	req := &request.Request{Error: err, RetryCount: retries}
+	if failure, ok := err.(awserr.RequestFailure); ok {
+		req.HTTPResponse = &http.Response{StatusCode: failure.StatusCode()}
+	}
+	if !r.ShouldRetry(req) {
+		return false
+	}
+	select {
+	case <-time.After(r.RetryRules(req)):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+var jitter = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
+
+// retryable reports whether the failure of r may be retried.
+func retryable(r *request.Request) bool {
+	if isAppend(r) {
+		return false
+	}
+	if err, ok := r.Error.(awserr.Error); ok {
+		switch err.Code() {
+		case request.CanceledErrorCode, ErrCodeStaleReadNotAllowed:
+			return false
+		}
+	}
+	if r.HTTPResponse != nil && r.HTTPResponse.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	return r.IsErrorRetryable() || isThrottle(r)
+}
+
+// isAppend reports whether r appends to an object.
+func isAppend(r *request.Request) bool {
+	input, ok := r.Params.(*PutObjectInput)
+	return ok && aws.StringValue(input.Range) == AppendRange
+}
+
+// isThrottle reports whether ECS asked to slow down r.
+func isThrottle(r *request.Request) bool {
+	if err, ok := r.Error.(awserr.Error); ok && err.Code() == "SlowDown" {
+		return true
+	}
+	return r.IsErrorThrottle()
+}
+
+// A RetryBudget limits retries across requests. It holds up to a number of
+// retries, each retry takes one, and they are given back at a steady rate.
+type RetryBudget struct {
+	capacity float64
+	rate     float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+// NewRetryBudget returns a full RetryBudget holding up to retries retries,
+// given back at perSecond retries per second.
+func NewRetryBudget(retries int, perSecond float64) *RetryBudget {
+	return &RetryBudget{
+		capacity: float64(retries),
+		rate:     perSecond,
+		tokens:   float64(retries),
+		last:     time.Now(),
+		now:      time.Now,
+	}
+}
+
+// Available returns the number of retries left in the budget.
+func (b *RetryBudget) Available() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	return int(b.tokens)
+}
+
+// withdraw takes a retry from the budget, if one is left.
+func (b *RetryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func (b *RetryBudget) refill() {
+	now := b.now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+}
+
+// lockedSource is a rand.Source safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
@@ -0,0 +1,175 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func testRetryer() *ecs.Retryer {
+	r := ecs.NewRetryer()
+	r.MinDelay = time.Millisecond
+	r.ThrottleDelay = 2 * time.Millisecond
+	r.MaxDelay = 5 * time.Millisecond
+	return r
+}
+
+// countingFault returns a fault matching the requests with method and the
+// number of requests it failed.
+func countingFault(method string, count int, status int, code string) (ecstest.Fault, *int32) {
+	var n int32
+	return ecstest.Fault{
+		Match: func(r *http.Request) bool {
+			if r.Method != method {
+				return false
+			}
+			atomic.AddInt32(&n, 1)
+			return true
+		},
+		Count:      count,
+		StatusCode: status,
+		Code:       code,
+	}, &n
+}
+
+func newRetryServer(t *testing.T) *ecstest.Server {
+	server := ecstest.NewServer()
+	client := server.Client()
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	_, err = client.PutObject(&s3.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: strings.NewReader("data")})
+	assert.Nil(t, err)
+	return server
+}
+
+func TestRetryerFailover(t *testing.T) {
+	server := newRetryServer(t)
+	defer server.Close()
+	client := server.Client(ecs.WithRetryer(testRetryer()))
+
+	fault, n := countingFault("GET", 2, http.StatusServiceUnavailable, "ServiceUnavailable")
+	server.AddFault(fault)
+	// the third attempt succeeds once the fault is used up
+	_, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(n))
+
+	server.ClearFaults()
+	fault, n = countingFault("GET", 0, http.StatusServiceUnavailable, "ServiceUnavailable")
+	server.AddFault(fault)
+	_, err = client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1+ecs.DefaultRetryerMaxRetries), atomic.LoadInt32(n))
+}
+
+func TestRetryerNotRetried(t *testing.T) {
+	server := newRetryServer(t)
+	defer server.Close()
+	client := server.Client(ecs.WithRetryer(testRetryer()))
+
+	fault, n := countingFault("GET", 0, http.StatusServiceUnavailable, ecs.ErrCodeStaleReadNotAllowed)
+	server.AddFault(fault)
+	_, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.True(t, ecs.IsStaleReadNotAllowed(err))
+	assert.Equal(t, int32(1), atomic.LoadInt32(n))
+
+	fault, n = countingFault("PUT", 0, http.StatusInternalServerError, "InternalError")
+	server.AddFault(fault)
+	_, err = client.AppendObject("b", "k", strings.NewReader("more"))
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(n))
+
+	// updates are idempotent
+	_, err = client.UpdateObjectRange("b", "k", 0, strings.NewReader("D"))
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(2+ecs.DefaultRetryerMaxRetries), atomic.LoadInt32(n))
+}
+
+func TestRetryerAppendConnectionError(t *testing.T) {
+	var n int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&n, 1)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if assert.Nil(t, err) {
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+	client := ecs.NewWithOptions(newLocalSession(server.URL), ecs.WithRetryer(testRetryer()))
+
+	_, err := client.AppendObject("b", "k", strings.NewReader("more"))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "RequestError", err.(awserr.Error).Code())
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&n))
+
+	atomic.StoreInt32(&n, 0)
+	_, err = client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1+ecs.DefaultRetryerMaxRetries), atomic.LoadInt32(&n))
+}
+
+func TestRetryerBudget(t *testing.T) {
+	server := newRetryServer(t)
+	defer server.Close()
+	retryer := testRetryer()
+	retryer.Budget = ecs.NewRetryBudget(2, 0)
+	client := server.Client(ecs.WithRetryer(retryer))
+
+	fault, n := countingFault("GET", 0, http.StatusServiceUnavailable, "ServiceUnavailable")
+	server.AddFault(fault)
+	_, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(n))
+	assert.Equal(t, 0, retryer.Budget.Available())
+
+	_, err = client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(4), atomic.LoadInt32(n))
+}
+
+func TestRetryerRetryRules(t *testing.T) {
+	retryer := ecs.NewRetryer()
+	r := &request.Request{Error: awserr.New("ServiceUnavailable", "", nil)}
+	for retries, max := range []time.Duration{100, 200, 400, 800} {
+		r.RetryCount = retries
+		for i := 0; i < 20; i++ {
+			delay := retryer.RetryRules(r)
+			assert.True(t, delay >= max*time.Millisecond/2 && delay <= max*time.Millisecond, delay)
+		}
+	}
+
+	r.RetryCount = 20
+	assert.True(t, retryer.RetryRules(r) <= ecs.DefaultRetryerMaxDelay)
+
+	r.RetryCount = 0
+	r.Error = awserr.New("SlowDown", "", nil)
+	delay := retryer.RetryRules(r)
+	assert.True(t, delay >= ecs.DefaultRetryerThrottleDelay/2 && delay <= ecs.DefaultRetryerThrottleDelay, delay)
+}
@@ -0,0 +1,240 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// StaleHeader is the response header ECS sets to true on reads that may
+// return out of date data, see GetObjectOutput.IsStale.
+const StaleHeader = "x-emc-is-stale"
+
+// ErrCodeStaleRead is the error code of a StaleReadError.
+const ErrCodeStaleRead = "StaleRead"
+
+// A StaleReadError is returned instead of the object by clients using
+// WithReadYourWrites when ECS flags a read as stale, or when the object read
+// is not the one last written through the client.
+type StaleReadError struct {
+	Bucket string
+	Key    string
+	// ExpectedETag is the ETag of the last write through the client, empty
+	// if it deleted the object or did not write it.
+	ExpectedETag string
+	// ETag is the ETag of the object read.
+	ETag string
+	// Flagged is set when ECS flagged the read as stale.
+	Flagged bool
+}
+
+// Code returns ErrCodeStaleRead.
+func (e *StaleReadError) Code() string {
+	return ErrCodeStaleRead
+}
+
+// Message describes the stale read.
+func (e *StaleReadError) Message() string {
+	switch {
+	case e.Flagged:
+		return fmt.Sprintf("read of %s/%s was flagged as stale", e.Bucket, e.Key)
+	case e.ExpectedETag == "":
+		return fmt.Sprintf("read %s/%s after it was deleted", e.Bucket, e.Key)
+	}
+	return fmt.Sprintf("read %s/%s with ETag %s, expected %s", e.Bucket, e.Key, e.ETag, e.ExpectedETag)
+}
+
+// OrigErr returns nil.
+func (e *StaleReadError) OrigErr() error {
+	return nil
+}
+
+func (e *StaleReadError) Error() string {
+	return ErrCodeStaleRead + ": " + e.Message()
+}
+
+// IsStaleRead reports whether err is a StaleReadError.
+func IsStaleRead(err error) bool {
+	_, ok := err.(*StaleReadError)
+	return ok
+}
+
+// A WriteCache remembers the ETags of the objects last written or deleted
+// through a client, so that reads of those objects can be checked, see
+// WithReadYourWrites. It holds a bounded number of objects, forgetting the
+// least recently written first. A WriteCache may be used concurrently.
+type WriteCache struct {
+	size int
+
+	mu      sync.Mutex
+	entries map[writeKey]*list.Element
+	order   *list.List
+}
+
+type writeKey struct {
+	bucket, key string
+}
+
+type writeEntry struct {
+	writeKey
+	// etag is empty for deleted objects.
+	etag string
+}
+
+// NewWriteCache returns a WriteCache holding up to size objects.
+func NewWriteCache(size int) *WriteCache {
+	return &WriteCache{size: size, entries: map[writeKey]*list.Element{}, order: list.New()}
+}
+
+// ETag returns the ETag of the last write of bucket/key through the client,
+// empty if the object was deleted. It returns false if the cache does not
+// hold the object.
+func (c *WriteCache) ETag(bucket, key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[writeKey{bucket, key}]
+	if !ok {
+		return "", false
+	}
+	return e.Value.(*writeEntry).etag, true
+}
+
+// Forget removes bucket/key from the cache, e.g. once another writer is
+// known to have written it.
+func (c *WriteCache) Forget(bucket, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[writeKey{bucket, key}]; ok {
+		c.order.Remove(e)
+		delete(c.entries, writeKey{bucket, key})
+	}
+}
+
+// record remembers etag as the last write of bucket/key.
+func (c *WriteCache) record(bucket, key, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	k := writeKey{bucket, key}
+	if e, ok := c.entries[k]; ok {
+		e.Value.(*writeEntry).etag = etag
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[k] = c.order.PushFront(&writeEntry{k, etag})
+	for c.order.Len() > c.size {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.entries, last.Value.(*writeEntry).writeKey)
+	}
+}
+
+const readYourWritesHandlerName = "ecs.ReadYourWritesHandler"
+
+// WithReadYourWrites returns a request option making reads consistent with
+// the writes recorded in cache: PutObject, CopyObject,
+// CompleteMultipartUpload and DeleteObject requests record the object they
+// write or delete, range updates and appends forget it, and GetObject and HeadObject requests of the latest
+// version of an object fail with a StaleReadError when ECS flags the read as
+// stale or when they read another object than the one last written.
+//
+// Passed to NewWithOptions, all the requests of the client share cache. The
+// check assumes the client is the only writer of the objects it wrote; use
+// WriteCache.Forget when it is not.
+func WithReadYourWrites(cache *WriteCache) request.Option {
+	return func(r *request.Request) {
+		r.Handlers.Unmarshal.RemoveByName(readYourWritesHandlerName)
+		r.Handlers.Unmarshal.PushBackNamed(request.NamedHandler{
+			Name: readYourWritesHandlerName,
+			Fn:   cache.handle,
+		})
+	}
+}
+
+// handle records the writes and checks the reads of successful requests.
+func (c *WriteCache) handle(r *request.Request) {
+	if r.Error != nil {
+		return
+	}
+	etag := r.HTTPResponse.Header.Get("ETag")
+	switch in := r.Params.(type) {
+	case *s3.GetObjectInput:
+		if in.VersionId == nil {
+			r.Error = c.check(r, aws.StringValue(in.Bucket), aws.StringValue(in.Key), etag)
+		}
+		if r.Error != nil {
+			closeBody(r.Data)
+		}
+	case *s3.HeadObjectInput:
+		if in.VersionId == nil {
+			r.Error = c.check(r, aws.StringValue(in.Bucket), aws.StringValue(in.Key), etag)
+		}
+	case *PutObjectInput:
+		if in.Range != nil {
+			// the ETag of a range update or append is not the one of the
+			// object, which reads would otherwise be checked against.
+			c.Forget(aws.StringValue(in.Bucket), aws.StringValue(in.Key))
+			return
+		}
+		c.record(aws.StringValue(in.Bucket), aws.StringValue(in.Key), etag)
+	case *s3.PutObjectInput:
+		c.record(aws.StringValue(in.Bucket), aws.StringValue(in.Key), etag)
+	case *s3.CopyObjectInput:
+		if out, ok := r.Data.(*s3.CopyObjectOutput); ok && out.CopyObjectResult != nil {
+			c.record(aws.StringValue(in.Bucket), aws.StringValue(in.Key), aws.StringValue(out.CopyObjectResult.ETag))
+		}
+	case *s3.CompleteMultipartUploadInput:
+		if out, ok := r.Data.(*s3.CompleteMultipartUploadOutput); ok {
+			c.record(aws.StringValue(in.Bucket), aws.StringValue(in.Key), aws.StringValue(out.ETag))
+		}
+	case *s3.DeleteObjectInput:
+		if in.VersionId == nil {
+			c.record(aws.StringValue(in.Bucket), aws.StringValue(in.Key), "")
+		}
+	}
+}
+
+// check returns a StaleReadError if the read of bucket/key returning etag is
+// stale.
+func (c *WriteCache) check(r *request.Request, bucket, key, etag string) error {
+	flagged, _ := strconv.ParseBool(r.HTTPResponse.Header.Get(StaleHeader))
+	expected, ok := c.ETag(bucket, key)
+	if flagged || ok && expected != etag {
+		return &StaleReadError{Bucket: bucket, Key: key, ExpectedETag: expected, ETag: etag, Flagged: flagged}
+	}
+	return nil
+}
+
+// closeBody closes the body of the output of a failed GetObject request.
+func closeBody(data interface{}) {
+	var body io.ReadCloser
+	switch out := data.(type) {
+	case *GetObjectOutput:
+		body = out.Body
+	case *s3.GetObjectOutput:
+		body = out.Body
+	}
+	if body != nil {
+		body.Close()
+	}
+}
@@ -0,0 +1,164 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaleFlag(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set(ecs.StaleHeader, "true")
+		w.Write([]byte("old"))
+	}))
+	defer server.Close()
+
+	client := newLocalClient(server.URL)
+	get, err := client.GetObjectExtension(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		assert.True(t, aws.BoolValue(get.IsStale))
+		get.Body.Close()
+	}
+	head, err := client.HeadObjectExtension(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		assert.True(t, aws.BoolValue(head.IsStale))
+	}
+
+	client = ecs.NewWithOptions(newLocalSession(server.URL), ecs.WithReadYourWrites(ecs.NewWriteCache(10)))
+	_, err = client.GetObjectExtension(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.IsType(t, &ecs.StaleReadError{}, err) {
+		assert.True(t, err.(*ecs.StaleReadError).Flagged)
+		assert.Equal(t, `"etag"`, err.(*ecs.StaleReadError).ETag)
+	}
+	_, err = client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.True(t, ecs.IsStaleRead(err))
+}
+
+func TestReadYourWrites(t *testing.T) {
+	server := ecstest.NewServer()
+	defer server.Close()
+	cache := ecs.NewWriteCache(10)
+	client := server.Client(ecs.WithReadYourWrites(cache))
+	// other plays a site that missed the writes of client
+	other := server.Client()
+
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	put, err := client.PutObjectExtension(&ecs.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: strings.NewReader("new")})
+	assert.Nil(t, err)
+	etag, ok := cache.ETag("b", "k")
+	assert.True(t, ok)
+	assert.Equal(t, aws.StringValue(put.ETag), etag)
+
+	get, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		data, _ := ioutil.ReadAll(get.Body)
+		get.Body.Close()
+		assert.Equal(t, "new", string(data))
+	}
+
+	_, err = other.PutObject(&s3.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: strings.NewReader("old")})
+	assert.Nil(t, err)
+	_, err = client.GetObjectExtension(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.IsType(t, &ecs.StaleReadError{}, err) {
+		assert.Equal(t, etag, err.(*ecs.StaleReadError).ExpectedETag)
+		assert.False(t, err.(*ecs.StaleReadError).Flagged)
+	}
+
+	// objects the client did not write are not checked
+	_, err = other.PutObject(&s3.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("other"), Body: strings.NewReader("data")})
+	assert.Nil(t, err)
+	_, err = client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("other")})
+	assert.Nil(t, err)
+
+	// reads after a delete must not find the object
+	_, err = client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.Nil(t, err)
+	_, err = other.PutObject(&s3.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: strings.NewReader("old")})
+	assert.Nil(t, err)
+	_, err = client.HeadObjectExtension(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.IsType(t, &ecs.StaleReadError{}, err) {
+		assert.Equal(t, "", err.(*ecs.StaleReadError).ExpectedETag)
+	}
+
+	cache.Forget("b", "k")
+	_, err = client.HeadObjectExtension(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.Nil(t, err)
+}
+
+func TestReadYourWritesRangeUpload(t *testing.T) {
+	server := ecstest.NewServer()
+	defer server.Close()
+	cache := ecs.NewWriteCache(10)
+	client := server.Client(ecs.WithReadYourWrites(cache))
+
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	data, _ := uploadData(4*1024 + 5)
+	_, err = testRangeUploader(client).Upload(&ecs.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: bytes.NewReader(data)})
+	assert.Nil(t, err)
+	// range PUTs do not return the ETag of the object
+	_, ok := cache.ETag("b", "k")
+	assert.False(t, ok)
+
+	get, err := client.GetObject(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		read, _ := ioutil.ReadAll(get.Body)
+		get.Body.Close()
+		assert.True(t, bytes.Equal(data, read))
+	}
+
+	_, err = client.PutObject(&s3.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: strings.NewReader("new")})
+	assert.Nil(t, err)
+	_, err = client.AppendObject("b", "k", strings.NewReader(" data"))
+	assert.Nil(t, err)
+	_, ok = cache.ETag("b", "k")
+	assert.False(t, ok)
+	_, err = client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.Nil(t, err)
+}
+
+func TestWriteCacheSize(t *testing.T) {
+	server := ecstest.NewServer()
+	defer server.Close()
+	cache := ecs.NewWriteCache(2)
+	client := server.Client(ecs.WithReadYourWrites(cache))
+
+	_, err := client.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	for _, key := range []string{"a", "b", "a", "c"} {
+		_, err = client.PutObject(&s3.PutObjectInput{Bucket: aws.String("b"), Key: aws.String(key), Body: strings.NewReader(key)})
+		assert.Nil(t, err)
+	}
+	_, ok := cache.ETag("b", "a")
+	assert.True(t, ok)
+	_, ok = cache.ETag("b", "b")
+	assert.False(t, ok)
+	_, ok = cache.ETag("b", "c")
+	assert.True(t, ok)
+}
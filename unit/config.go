@@ -15,14 +15,30 @@ package unit
  * permissions and limitations under the License.
  */
 
-import "github.com/jacobstr/confer"
+import (
+	"os"
+	"strings"
 
-// LoadConfig loads default test_config.yaml file
+	"github.com/jacobstr/confer"
+)
+
+// LoadConfig loads default test_config.yaml file. The config is empty if the
+// file does not exist.
 func LoadConfig() *confer.Config {
 	config := confer.NewConfig()
+	if _, err := os.Stat("test_config.yaml"); os.IsNotExist(err) {
+		return config
+	}
 	err := config.ReadPaths("test_config.yaml")
 	if err != nil {
 		panic(err)
 	}
 	return config
 }
+
+// Configured reports whether config names a live ECS S3 endpoint, rather than
+// being empty or holding the <placeholder> values of the sample config.
+func Configured(config *confer.Config) bool {
+	endpoint := config.GetString("s3.endpoint")
+	return endpoint != "" && !strings.HasPrefix(endpoint, "<")
+}
@@ -0,0 +1,30 @@
+package unit
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"github.com/EMCECS/ecs-object-client-go/mgmt"
+	"github.com/jacobstr/confer"
+)
+
+// GetMgmtClient is to get management API client to ECS server
+func GetMgmtClient(config *confer.Config) *mgmt.Client {
+	return mgmt.New(mgmt.Config{
+		Endpoint: config.GetString("mgmt.endpoint"),
+		Username: config.GetString("mgmt.username"),
+		Password: config.GetString("mgmt.password"),
+	})
+}
@@ -17,6 +17,7 @@ package unit
 
 import (
 	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
@@ -24,12 +25,26 @@ import (
 	"github.com/jacobstr/confer"
 )
 
-// Session is a shared session for unit tests to use.
-var Session = GetS3Client(LoadConfig())
+// Session is a shared session for unit tests to use. It is set by Open.
+var Session *ecs.S3
+
+// Open sets Session to a client of the ECS server of the default config.
+// Without a configured endpoint the client talks to a local ecstest server
+// instead. The returned function closes that server; call it from TestMain
+// once the tests are done.
+func Open() (close func()) {
+	config := LoadConfig()
+	if !Configured(config) {
+		server := ecstest.NewServer()
+		Session = server.Client()
+		return server.Close
+	}
+	Session = GetS3Client(config)
+	return func() {}
+}
 
 // GetS3Client is to get S3 client to ECS server
 func GetS3Client(config *confer.Config) *ecs.S3 {
-
 	s3Config := &aws.Config{
 		Credentials: credentials.NewStaticCredentials(config.GetString("s3.access_key"), config.GetString("s3.secret_key"), ""),
 		Endpoint:    aws.String(config.GetString("s3.endpoint")),
@@ -0,0 +1,444 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// Settings of the Uploader returned by NewUploader.
+const (
+	// MinUploadPartSize is the smallest part of a multipart upload but the
+	// last one.
+	MinUploadPartSize int64 = 5 * 1024 * 1024
+	// MaxUploadParts is the largest number of parts of a multipart upload.
+	MaxUploadParts = 10000
+
+	DefaultUploadPartSize    = MinUploadPartSize
+	DefaultUploadConcurrency = 5
+)
+
+// ErrCodeContentMD5Mismatch is the error code returned when the
+// x-emc-content-md5 ECS computed for an object is not the MD5 of the data
+// uploaded or downloaded.
+const ErrCodeContentMD5Mismatch = "ContentMD5Mismatch"
+
+// UploadAPI is the operations an Uploader writes objects with. It is
+// implemented by S3 and by implementations of ecsiface.ECSAPI.
+type UploadAPI interface {
+	PutObjectExtensionWithContext(aws.Context, *PutObjectInput, ...request.Option) (*PutObjectOutput, error)
+	HeadObjectExtensionWithContext(aws.Context, *s3.HeadObjectInput, ...request.Option) (*HeadObjectOutput, error)
+	CreateMultipartUploadWithContext(aws.Context, *s3.CreateMultipartUploadInput, ...request.Option) (*s3.CreateMultipartUploadOutput, error)
+	UploadPartWithContext(aws.Context, *s3.UploadPartInput, ...request.Option) (*s3.UploadPartOutput, error)
+	CompleteMultipartUploadWithContext(aws.Context, *s3.CompleteMultipartUploadInput, ...request.Option) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUploadWithContext(aws.Context, *s3.AbortMultipartUploadInput, ...request.Option) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// An Uploader writes objects of any size, reading their data as a stream.
+// Objects smaller than PartSize are written with a single PutObjectExtension,
+// larger ones with a multipart upload of up to Concurrency parts at a time.
+//
+// Unlike s3manager.Uploader, it keeps the ECS fields of PutObjectInput, such
+// as RetentionPeriod and RetentionPolicy, and checks that the
+// x-emc-content-md5 of the object written is the MD5 of the data read. An
+// Uploader may be used concurrently.
+//
+//	uploader := ecs.NewUploader(client)
+//	out, err := uploader.Upload(&ecs.PutObjectInput{
+//	    Bucket:          aws.String("bucket"),
+//	    Key:             aws.String("key"),
+//	    Body:            file,
+//	    RetentionPeriod: aws.Int64(3600),
+//	})
+type Uploader struct {
+	// PartSize is the size of the parts of multipart uploads, at least
+	// MinUploadPartSize. Each upload in progress buffers up to Concurrency+1
+	// parts.
+	PartSize int64
+	// Concurrency is the number of parts of an upload sent at a time.
+	Concurrency int
+	// MaxUploadParts is the largest number of parts of an upload, at most the
+	// constant MaxUploadParts. Larger uploads fail with TotalPartsExceeded.
+	MaxUploadParts int64
+	// RequestOptions apply to every request of the uploads.
+	RequestOptions []request.Option
+
+	S3 UploadAPI
+}
+
+// NewUploader returns an Uploader writing objects with client, with the
+// default settings changed by options.
+func NewUploader(client UploadAPI, options ...func(*Uploader)) *Uploader {
+	u := &Uploader{
+		PartSize:       DefaultUploadPartSize,
+		Concurrency:    DefaultUploadConcurrency,
+		MaxUploadParts: MaxUploadParts,
+		S3:             client,
+	}
+	for _, option := range options {
+		option(u)
+	}
+	return u
+}
+
+// UploadOutput describes an object written by an Uploader.
+type UploadOutput struct {
+	// ContentMD5EMC is the MD5 ECS computed for the object, nil if ECS did
+	// not return it.
+	ContentMD5EMC *string
+	ETag          *string
+	// UploadID is the ID of the multipart upload, empty if the object was
+	// written with a single request.
+	UploadID  string
+	VersionId *string
+}
+
+// Upload writes the object described by input, reading its data from Body
+// until io.EOF. Range and ContentLength must not be set.
+//
+// If the upload fails, a multipart upload is aborted. If the object was
+// written but ECS returned another x-emc-content-md5 than the MD5 of the
+// data read, Upload returns the output with an error with the code
+// ErrCodeContentMD5Mismatch.
+func (u *Uploader) Upload(input *PutObjectInput, options ...func(*Uploader)) (*UploadOutput, error) {
+	return u.UploadWithContext(aws.BackgroundContext(), input, options...)
+}
+
+// UploadWithContext is the same as Upload with the addition of the ability to
+// pass a context. The upload stops when ctx is done.
+func (u *Uploader) UploadWithContext(ctx aws.Context, input *PutObjectInput, options ...func(*Uploader)) (*UploadOutput, error) {
+	settings := *u
+	for _, option := range options {
+		option(&settings)
+	}
+	if err := settings.validate(input); err != nil {
+		return nil, err
+	}
+
+	up := &upload{Uploader: &settings, partReader: newPartReader(input.Body, settings.PartSize), ctx: ctx, input: input}
+	first, err := up.nextPart()
+	if err == io.EOF {
+		return up.single(first)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return up.multipart(first)
+}
+
+func (u *Uploader) validate(input *PutObjectInput) error {
+	invalidParams := request.ErrInvalidParams{Context: "Uploader"}
+	if u.PartSize < MinUploadPartSize {
+		invalidParams.Add(request.NewErrParamMinValue("PartSize", float64(MinUploadPartSize)))
+	}
+	if u.Concurrency < 1 {
+		invalidParams.Add(request.NewErrParamMinValue("Concurrency", 1))
+	}
+	if u.MaxUploadParts < 1 || u.MaxUploadParts > MaxUploadParts {
+		invalidParams.Add(NewErrParamFormat("MaxUploadParts", fmt.Sprintf("must be between 1 and %d", MaxUploadParts)))
+	}
+	if input == nil {
+		invalidParams.Add(request.NewErrParamRequired("PutObjectInput"))
+		return invalidParams
+	}
+	if input.Body == nil {
+		invalidParams.Add(request.NewErrParamRequired("Body"))
+	}
+	if input.Range != nil {
+		invalidParams.Add(NewErrParamFormat("Range", "cannot be uploaded, use UpdateObjectRange or AppendObject"))
+	}
+	if input.ContentLength != nil {
+		invalidParams.Add(NewErrParamFormat("ContentLength", "is computed by the upload"))
+	}
+	if invalidParams.Len() > 0 {
+		return invalidParams
+	}
+	return nil
+}
+
+// upload is an upload in progress.
+type upload struct {
+	*Uploader
+	*partReader
+	ctx   aws.Context
+	input *PutObjectInput
+}
+
+// A partReader reads data in parts, computing its MD5.
+type partReader struct {
+	body io.Reader
+	size int64
+	hash hash.Hash
+}
+
+func newPartReader(body io.Reader, size int64) *partReader {
+	return &partReader{body: body, size: size, hash: md5.New()}
+}
+
+// nextPart reads the next part of the data. It returns io.EOF with the last
+// part, which may be empty.
+func (r *partReader) nextPart() ([]byte, error) {
+	part := make([]byte, r.size)
+	n, err := io.ReadFull(r.body, part)
+	switch err {
+	case nil:
+		// A part is only known to be the last one once the next read
+		// returns io.EOF, so a full part is never the last.
+	case io.EOF, io.ErrUnexpectedEOF:
+		err = io.EOF
+	default:
+		return nil, awserr.New("ReadRequestBody", "read upload data failed", err)
+	}
+	r.hash.Write(part[:n])
+	return part[:n], err
+}
+
+// verify checks contentMD5EMC, the x-emc-content-md5 of the object written
+// from input, if any, against the MD5 of the data read.
+func (r *partReader) verify(input *PutObjectInput, contentMD5EMC *string) error {
+	sum := hex.EncodeToString(r.hash.Sum(nil))
+	if contentMD5EMC == nil || *contentMD5EMC == sum {
+		return nil
+	}
+	return awserr.New(ErrCodeContentMD5Mismatch, fmt.Sprintf("x-emc-content-md5 of %s/%s is %s, expected %s",
+		aws.StringValue(input.Bucket), aws.StringValue(input.Key), *contentMD5EMC, sum), nil)
+}
+
+// single writes data with one PutObjectExtension.
+func (u *upload) single(data []byte) (*UploadOutput, error) {
+	input := *u.input
+	input.Body = bytes.NewReader(data)
+	out, err := u.S3.PutObjectExtensionWithContext(u.ctx, &input, u.RequestOptions...)
+	if err != nil {
+		return nil, err
+	}
+	result := &UploadOutput{ContentMD5EMC: out.ContentMD5EMC, ETag: out.ETag, VersionId: out.VersionId}
+	return result, u.verify(u.input, result.ContentMD5EMC)
+}
+
+// multipart writes first and the rest of the data with a multipart upload.
+func (u *upload) multipart(first []byte) (*UploadOutput, error) {
+	opts := append([]request.Option{initiateHeaders(u.input)}, u.RequestOptions...)
+	created, err := u.S3.CreateMultipartUploadWithContext(u.ctx, initiateInput(u.input), opts...)
+	if err != nil {
+		return nil, err
+	}
+	id := aws.StringValue(created.UploadId)
+
+	parts, err := u.uploadParts(id, first)
+	if err != nil {
+		u.abort(id)
+		return nil, err
+	}
+	completed, err := u.S3.CompleteMultipartUploadWithContext(u.ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          u.input.Bucket,
+		Key:             u.input.Key,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+		RequestPayer:    u.input.RequestPayer,
+		UploadId:        created.UploadId,
+	}, u.RequestOptions...)
+	if err != nil {
+		u.abort(id)
+		return nil, err
+	}
+
+	result := &UploadOutput{ETag: completed.ETag, UploadID: id, VersionId: completed.VersionId}
+	// The ETag pins the object just written, in case it is overwritten
+	// meanwhile.
+	head, err := u.S3.HeadObjectExtensionWithContext(u.ctx, &s3.HeadObjectInput{
+		Bucket:               u.input.Bucket,
+		IfMatch:              completed.ETag,
+		Key:                  u.input.Key,
+		RequestPayer:         u.input.RequestPayer,
+		SSECustomerAlgorithm: u.input.SSECustomerAlgorithm,
+		SSECustomerKey:       u.input.SSECustomerKey,
+		SSECustomerKeyMD5:    u.input.SSECustomerKeyMD5,
+		VersionId:            completed.VersionId,
+	}, u.RequestOptions...)
+	if err != nil {
+		return result, err
+	}
+	result.ContentMD5EMC = head.ContentMD5EMC
+	return result, u.verify(u.input, result.ContentMD5EMC)
+}
+
+// uploadParts uploads first and the following parts of the upload id,
+// Concurrency at a time, and returns them in order.
+func (u *upload) uploadParts(id string, first []byte) ([]*s3.CompletedPart, error) {
+	ctx, cancel := context.WithCancel(u.ctx)
+	defer cancel()
+
+	type part struct {
+		number int64
+		data   []byte
+	}
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		completed []*s3.CompletedPart
+		failure   error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failure == nil {
+			failure = err
+			cancel()
+		}
+	}
+	parts := make(chan part)
+	for i := 0; i < u.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := range parts {
+				out, err := u.S3.UploadPartWithContext(ctx, &s3.UploadPartInput{
+					Body:                 bytes.NewReader(p.data),
+					Bucket:               u.input.Bucket,
+					Key:                  u.input.Key,
+					PartNumber:           aws.Int64(p.number),
+					RequestPayer:         u.input.RequestPayer,
+					SSECustomerAlgorithm: u.input.SSECustomerAlgorithm,
+					SSECustomerKey:       u.input.SSECustomerKey,
+					SSECustomerKeyMD5:    u.input.SSECustomerKeyMD5,
+					UploadId:             aws.String(id),
+				}, u.RequestOptions...)
+				if err != nil {
+					fail(err)
+					continue
+				}
+				mu.Lock()
+				completed = append(completed, &s3.CompletedPart{ETag: out.ETag, PartNumber: aws.Int64(p.number)})
+				mu.Unlock()
+			}
+		}()
+	}
+
+	data, err := first, error(nil)
+	for number := int64(1); ; number++ {
+		if len(data) > 0 && number > u.MaxUploadParts {
+			fail(awserr.New("TotalPartsExceeded", fmt.Sprintf("upload exceeds %d parts, increase PartSize", u.MaxUploadParts), nil))
+			break
+		}
+		if len(data) > 0 || number == 1 {
+			select {
+			case parts <- part{number, data}:
+			case <-ctx.Done():
+				fail(ctx.Err())
+			}
+		}
+		if err != nil || ctx.Err() != nil {
+			break
+		}
+		if data, err = u.nextPart(); err != nil && err != io.EOF {
+			fail(err)
+			break
+		}
+	}
+	close(parts)
+	wg.Wait()
+
+	if failure != nil {
+		if failure == context.Canceled || failure == context.DeadlineExceeded {
+			return nil, awserr.New(request.CanceledErrorCode, "upload context canceled", failure)
+		}
+		return nil, failure
+	}
+	sort.Sort(completedParts(completed))
+	return completed, nil
+}
+
+type completedParts []*s3.CompletedPart
+
+func (p completedParts) Len() int           { return len(p) }
+func (p completedParts) Less(i, j int) bool { return *p[i].PartNumber < *p[j].PartNumber }
+func (p completedParts) Swap(i, j int)      { p[i], p[j] = p[j], p[i] }
+
+// abort aborts the multipart upload id, even if the context of the upload
+// is done. Failures are ignored: the parts are left for a lifecycle rule or a
+// later abort to remove.
+func (u *upload) abort(id string) {
+	u.S3.AbortMultipartUploadWithContext(aws.BackgroundContext(), &s3.AbortMultipartUploadInput{
+		Bucket:       u.input.Bucket,
+		Key:          u.input.Key,
+		RequestPayer: u.input.RequestPayer,
+		UploadId:     aws.String(id),
+	}, u.RequestOptions...)
+}
+
+// initiateInput returns the CreateMultipartUploadInput of the fields of
+// input s3.CreateMultipartUploadInput has.
+func initiateInput(input *PutObjectInput) *s3.CreateMultipartUploadInput {
+	return &s3.CreateMultipartUploadInput{
+		ACL:                     input.ACL,
+		Bucket:                  input.Bucket,
+		CacheControl:            input.CacheControl,
+		ContentDisposition:      input.ContentDisposition,
+		ContentEncoding:         input.ContentEncoding,
+		ContentLanguage:         input.ContentLanguage,
+		ContentType:             input.ContentType,
+		Expires:                 input.Expires,
+		GrantFullControl:        input.GrantFullControl,
+		GrantRead:               input.GrantRead,
+		GrantReadACP:            input.GrantReadACP,
+		GrantWriteACP:           input.GrantWriteACP,
+		Key:                     input.Key,
+		Metadata:                input.Metadata,
+		RequestPayer:            input.RequestPayer,
+		SSECustomerAlgorithm:    input.SSECustomerAlgorithm,
+		SSECustomerKey:          input.SSECustomerKey,
+		SSECustomerKeyMD5:       input.SSECustomerKeyMD5,
+		SSEKMSKeyId:             input.SSEKMSKeyId,
+		ServerSideEncryption:    input.ServerSideEncryption,
+		StorageClass:            input.StorageClass,
+		WebsiteRedirectLocation: input.WebsiteRedirectLocation,
+	}
+}
+
+// initiateHeaders returns a request option adding the headers of the fields
+// of input s3.CreateMultipartUploadInput does not have to the initiate
+// request.
+func initiateHeaders(input *PutObjectInput) request.Option {
+	return func(r *request.Request) {
+		h := r.HTTPRequest.Header
+		if input.IfNoneMatch != nil {
+			h.Set("If-None-Match", *input.IfNoneMatch)
+		}
+		if input.RetentionPeriod != nil {
+			h.Set("x-emc-retention-period", strconv.FormatInt(*input.RetentionPeriod, 10))
+		}
+		if input.RetentionPolicy != nil {
+			h.Set("x-emc-retention-policy", *input.RetentionPolicy)
+		}
+		if input.Tagging != nil {
+			h.Set("x-amz-tagging", *input.Tagging)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package ecs_test
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/EMCECS/ecs-object-client-go"
+	"github.com/EMCECS/ecs-object-client-go/ecstest"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+)
+
+// uploadData returns size bytes of test data and their hex MD5.
+func uploadData(size int64) ([]byte, string) {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	sum := md5.Sum(data)
+	return data, hex.EncodeToString(sum[:])
+}
+
+// requestLog records the requests served by a Server.
+type requestLog struct {
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (l *requestLog) fault() ecstest.Fault {
+	return ecstest.Fault{Match: func(r *http.Request) bool {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.requests = append(l.requests, r)
+		return true
+	}}
+}
+
+// find returns the requests with method and the query parameter param.
+func (l *requestLog) find(method, param string) []*http.Request {
+	return l.filter(func(r *http.Request) bool {
+		_, ok := r.URL.Query()[param]
+		return ok && r.Method == method
+	})
+}
+
+// filter returns the requests matching match.
+func (l *requestLog) filter(match func(*http.Request) bool) []*http.Request {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var found []*http.Request
+	for _, r := range l.requests {
+		if match(r) {
+			found = append(found, r)
+		}
+	}
+	return found
+}
+
+func newUploadServer(t *testing.T) (*ecstest.Server, *requestLog) {
+	server := ecstest.NewServer()
+	_, err := server.Client().CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+	log := &requestLog{}
+	server.AddFault(log.fault())
+	return server, log
+}
+
+func TestUploadSingle(t *testing.T) {
+	server, log := newUploadServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	data, sum := uploadData(1024)
+	out, err := ecs.NewUploader(client).Upload(&ecs.PutObjectInput{
+		Bucket:          aws.String("b"),
+		Key:             aws.String("k"),
+		Body:            bytes.NewReader(data),
+		RetentionPeriod: aws.Int64(3600),
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+		assert.Equal(t, "", out.UploadID)
+	}
+	assert.Len(t, log.find("POST", "uploads"), 0)
+
+	head, err := client.HeadObjectExtension(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(1024), aws.Int64Value(head.ContentLength))
+		assert.Equal(t, int64(3600), aws.Int64Value(head.RetentionPeriod))
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	server, log := newUploadServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	data, sum := uploadData(2*ecs.MinUploadPartSize + 1000)
+	uploader := ecs.NewUploader(client, func(u *ecs.Uploader) {
+		u.Concurrency = 2
+	})
+	out, err := uploader.Upload(&ecs.PutObjectInput{
+		Bucket:          aws.String("b"),
+		Key:             aws.String("k"),
+		Body:            bytes.NewReader(data),
+		ContentType:     aws.String("application/x-test"),
+		Metadata:        map[string]*string{"color": aws.String("red")},
+		RetentionPeriod: aws.Int64(3600),
+		RetentionPolicy: aws.String("policy"),
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+		assert.NotEqual(t, "", out.UploadID)
+	}
+
+	initiate := log.find("POST", "uploads")
+	if assert.Len(t, initiate, 1) {
+		assert.Equal(t, "3600", initiate[0].Header.Get("x-emc-retention-period"))
+		assert.Equal(t, "policy", initiate[0].Header.Get("x-emc-retention-policy"))
+	}
+	assert.Len(t, log.find("PUT", "partNumber"), 3)
+
+	get, err := client.GetObjectExtension(&s3.GetObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	if assert.Nil(t, err) {
+		read, _ := ioutil.ReadAll(get.Body)
+		get.Body.Close()
+		assert.True(t, bytes.Equal(data, read))
+		assert.Equal(t, "application/x-test", aws.StringValue(get.ContentType))
+		assert.Equal(t, "red", aws.StringValue(get.Metadata["Color"]))
+		assert.Equal(t, int64(3600), aws.Int64Value(get.RetentionPeriod))
+		assert.Equal(t, "policy", aws.StringValue(get.RetentionPolicy))
+	}
+}
+
+func TestUploadExactParts(t *testing.T) {
+	server, log := newUploadServer(t)
+	defer server.Close()
+
+	data, sum := uploadData(2 * ecs.MinUploadPartSize)
+	out, err := ecs.NewUploader(server.Client()).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   bytes.NewReader(data),
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+	}
+	// no empty part is sent after the last full one
+	assert.Len(t, log.find("PUT", "partNumber"), 2)
+}
+
+func TestUploadMaxParts(t *testing.T) {
+	server, log := newUploadServer(t)
+	defer server.Close()
+	uploader := ecs.NewUploader(server.Client(), func(u *ecs.Uploader) {
+		u.MaxUploadParts = 2
+	})
+
+	// exactly MaxUploadParts full parts fit
+	data, sum := uploadData(2 * ecs.MinUploadPartSize)
+	out, err := uploader.Upload(&ecs.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: bytes.NewReader(data)})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+	}
+
+	data, _ = uploadData(2*ecs.MinUploadPartSize + 1)
+	_, err = uploader.Upload(&ecs.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: bytes.NewReader(data)})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "TotalPartsExceeded", err.(awserr.Error).Code())
+	}
+	assert.Len(t, log.find("DELETE", "uploadId"), 1)
+
+	_, err = uploader.Upload(&ecs.PutObjectInput{Bucket: aws.String("b"), Key: aws.String("k"), Body: bytes.NewReader(data)}, func(u *ecs.Uploader) {
+		u.MaxUploadParts = ecs.MaxUploadParts + 1
+	})
+	assert.IsType(t, request.ErrInvalidParams{}, err)
+}
+
+func TestUploadPartFailure(t *testing.T) {
+	server, log := newUploadServer(t)
+	defer server.Close()
+	client := server.Client()
+
+	server.ClearFaults()
+	server.AddFault(ecstest.Fault{
+		Match: func(r *http.Request) bool {
+			return r.URL.Query().Get("partNumber") == "2"
+		},
+		StatusCode: http.StatusInternalServerError,
+		Code:       "InternalError",
+	})
+	server.AddFault(log.fault())
+
+	data, _ := uploadData(3 * ecs.MinUploadPartSize)
+	_, err := ecs.NewUploader(client).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   bytes.NewReader(data),
+	})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "InternalError", err.(awserr.Error).Code())
+	}
+	assert.Len(t, log.find("DELETE", "uploadId"), 1)
+	assert.Len(t, log.find("POST", "uploadId"), 0)
+
+	_, err = client.HeadObject(&s3.HeadObjectInput{Bucket: aws.String("b"), Key: aws.String("k")})
+	assert.NotNil(t, err)
+}
+
+func TestUploadContentMD5Mismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"etag"`)
+		w.Header().Set("x-emc-content-md5", "0123456789abcdef0123456789abcdef")
+	}))
+	defer server.Close()
+
+	out, err := ecs.NewUploader(newLocalClient(server.URL)).Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   strings.NewReader("data"),
+	})
+	if assert.NotNil(t, err) {
+		assert.Equal(t, ecs.ErrCodeContentMD5Mismatch, err.(awserr.Error).Code())
+	}
+	if assert.NotNil(t, out) {
+		assert.Equal(t, `"etag"`, aws.StringValue(out.ETag))
+	}
+}
+
+func TestUploadValidate(t *testing.T) {
+	uploader := ecs.NewUploader(ecstest.NewFake())
+
+	_, err := uploader.Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   strings.NewReader("data"),
+		Range:  aws.String(ecs.AppendRange),
+	})
+	assert.NotNil(t, err)
+
+	_, err = uploader.Upload(&ecs.PutObjectInput{
+		Bucket: aws.String("b"),
+		Key:    aws.String("k"),
+		Body:   strings.NewReader("data"),
+	}, func(u *ecs.Uploader) {
+		u.PartSize = 1024
+	})
+	assert.NotNil(t, err)
+}
+
+func TestUploadFake(t *testing.T) {
+	fake := ecstest.NewFake()
+	_, err := fake.CreateBucketExtension(&ecs.CreateBucketInput{Bucket: aws.String("b")})
+	assert.Nil(t, err)
+
+	data, sum := uploadData(1024)
+	out, err := ecs.NewUploader(fake).Upload(&ecs.PutObjectInput{
+		Bucket:          aws.String("b"),
+		Key:             aws.String("k"),
+		Body:            bytes.NewReader(data),
+		RetentionPolicy: aws.String("policy"),
+	})
+	if assert.Nil(t, err) {
+		assert.Equal(t, sum, aws.StringValue(out.ContentMD5EMC))
+	}
+}
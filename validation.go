@@ -0,0 +1,86 @@
+package ecs
+
+/*
+ * Copyright 2017 Dell EMC Corporation. All Rights Reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License").
+ * You may not use this file except in compliance with the License.
+ * A copy of the License is located at
+ *
+ * http://www.apache.org/licenses/LICENSE-2.0.txt
+ *
+ * or in the "license" file accompanying this file. This file is distributed
+ * on an "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either
+ * express or implied. See the License for the specific language governing
+ * permissions and limitations under the License.
+ */
+
+import "fmt"
+
+// ParamFormatErrCode is the error code for malformed parameter values.
+const ParamFormatErrCode = "ParamFormatError"
+
+// An ErrParamFormat represents a parameter whose value is malformed.
+// It satisfies request.ErrInvalidParam.
+type ErrParamFormat struct {
+	context       string
+	nestedContext string
+	field         string
+	msg           string
+}
+
+// NewErrParamFormat creates a new malformed parameter error.
+func NewErrParamFormat(field string, msg string) *ErrParamFormat {
+	return &ErrParamFormat{
+		field: field,
+		msg:   msg,
+	}
+}
+
+// Code returns the error code for the type of invalid parameter.
+func (e *ErrParamFormat) Code() string {
+	return ParamFormatErrCode
+}
+
+// Message returns the reason the parameter was invalid, and its context.
+func (e *ErrParamFormat) Message() string {
+	return fmt.Sprintf("%s, %s.", e.msg, e.Field())
+}
+
+// Error returns the string version of the invalid parameter error.
+func (e *ErrParamFormat) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code(), e.Message())
+}
+
+// OrigErr returns nil, Implemented for awserr.Error interface.
+func (e *ErrParamFormat) OrigErr() error {
+	return nil
+}
+
+// Field Returns the field and context the error occurred.
+func (e *ErrParamFormat) Field() string {
+	field := e.context
+	if len(field) > 0 {
+		field += "."
+	}
+	if len(e.nestedContext) > 0 {
+		field += fmt.Sprintf("%s.", e.nestedContext)
+	}
+	field += e.field
+
+	return field
+}
+
+// SetContext updates the base context of the error.
+func (e *ErrParamFormat) SetContext(ctx string) {
+	e.context = ctx
+}
+
+// AddNestedContext prepends a context to the field's path.
+func (e *ErrParamFormat) AddNestedContext(ctx string) {
+	if len(e.nestedContext) == 0 {
+		e.nestedContext = ctx
+	} else {
+		e.nestedContext = fmt.Sprintf("%s.%s", ctx, e.nestedContext)
+	}
+}